@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vertoforce/go-ioc/ioc"
+)
+
+var defangCommand = &cobra.Command{
+	Use:   "defang [file...]",
+	Short: "Defang every IOC in a document for safe sharing, reading stdin if no files are given",
+	Long: "Prints the document with every IOC defanged (ex: hxxp[://]evil[.]com) and everything else left as is.  " +
+		"HTML documents only have their text and links (href) rewritten.",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		rewriteDocuments(args, ioc.DefangText, ioc.DefangHTML)
+	},
+}
+
+var fangCommand = &cobra.Command{
+	Use:   "fang [file...]",
+	Short: "Fang every defanged IOC in a document for tooling, reading stdin if no files are given",
+	Long: "Prints the document with every defanged IOC fanged (ex: http://evil.com) and everything else left as is.  " +
+		"HTML documents only have their text and links (href) rewritten.",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		rewriteDocuments(args, ioc.FangText, ioc.FangHTML)
+	},
+}
+
+// rewriteDocuments Rewrite stdin, or each file, with the text or HTML rewrite and print it (or save it to the output file)
+func rewriteDocuments(paths []string, rewriteText func(string) string, rewriteHTML func(string) string) {
+	documents := [][]byte{}
+	if len(paths) == 0 {
+		stdin, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		documents = append(documents, stdin)
+	}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		documents = append(documents, data)
+	}
+
+	output := ""
+	for _, document := range documents {
+		if ioc.DetectContentType(document) == ioc.ContentHTML {
+			output += rewriteHTML(string(document))
+		} else {
+			output += rewriteText(string(document))
+		}
+	}
+	writeOutput(output)
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vertoforce/go-ioc/ioc"
+)
+
+var emailCommand = &cobra.Command{
+	Use:   "email [EML file...]",
+	Short: "Find IOCs in email messages, reading stdin if no files are given",
+	Long: "Parses RFC 5322/MIME messages, finding IOCs in the bodies and attachments, and the sender, reply-to, return-path, Received hops, and Message-ID domain in the headers.  " +
+		"Header indicators have the header they came from in their metadata, ex: -t '{{.IOC}} {{.Metadata.header}}'",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		iocs := []*ioc.FoundIOC{}
+		if len(args) == 0 {
+			found, err := getIOCsFromEmail(os.Stdin)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			iocs = append(iocs, found...)
+		}
+		for _, path := range args {
+			file, err := os.Open(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+			found, err := getIOCsFromEmail(file)
+			file.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			}
+			for _, ioc := range found {
+				ioc.Source = path
+			}
+			iocs = append(iocs, found...)
+		}
+		standardizeFoundDefangs(iocs)
+		printIOCHelper(iocs)
+	},
+}
+
+// getIOCsFromEmail Get the IOCs from the email, or only its headers
+func getIOCsFromEmail(reader io.Reader) ([]*ioc.FoundIOC, error) {
+	if !emailHeaders {
+		return ioc.GetIOCsFromEmail(reader, getFangedIOCs)
+	}
+	msg, err := mail.ReadMessage(reader)
+	if err != nil {
+		return nil, err
+	}
+	return ioc.GetIOCsFromEmailHeader(msg.Header), nil
+}
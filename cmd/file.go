@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vertoforce/go-ioc/ioc"
+)
+
+var fileCommand = &cobra.Command{
+	Use:   "file [path or glob...]",
+	Short: "Find IOCs in files, recursing in to directories",
+	Long: "Each file's content type is detected, so HTML and PDF files only have their `text` searched, and Office files (DOCX, XLSX, PPTX) have their text, comments, and hyperlinks searched.  " +
+		"Archives and compressed files (zip, tar, gzip, bzip2, xz) are opened up to the depth and size limits, trying the passwords on encrypted zips.  " +
+		"Binary files have their ASCII and UTF-16 strings searched, and PE and ELF executables have their MD5, SHA1, SHA256, and imports included, with their imphash and section hashes as metadata.  " +
+		"Packet captures (pcap, pcapng) have the DNS queries and answers, HTTP hosts and URLs, TLS SNIs, and remote IPs in them, with the time and flow they were first seen in their metadata.  " +
+		"With --decode, base64, hex, and URL encoded text in text, HTML, and PDFs is decoded (up to 3 layers) and searched, and those IOCs have the encodings as their metadata, ex: -t '{{.IOC}} {{.Metadata.encoding}}'.  " +
+		"With --deobfuscate, scripts in text, HTML, and PDFs are searched after resolving string concatenation and char codes ('ht'+'tp', String.fromCharCode, [char], -join, backticks).  " +
+		"Every IOC is labeled with the path it was found in, and IOCs in archives have the member path in their metadata, ex: -t '{{.IOC}} {{.Metadata.member}}'",
+	Args: cobra.MinimumNArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		paths := findFiles(args)
+		iocs := []*ioc.FoundIOC{}
+		for _, path := range paths {
+			found, err := ioc.GetIOCsFromFileWithOptions(path, ioc.ContentOptions{
+				GetFangedIOCs:   getFangedIOCs,
+				MaxDepth:        maxDepth,
+				MaxSize:         maxSize,
+				Passwords:       passwords,
+				MinStringLength: minStringLength,
+				Decode:          decode,
+				Deobfuscate:     deobfuscate,
+			})
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			iocs = append(iocs, found...)
+		}
+		standardizeFoundDefangs(iocs)
+		printIOCHelper(iocs)
+	},
+}
+
+// findFiles Expand the globs and walk the directories in args to get the files to search.
+// Files named directly are always searched, files found in directories must match the include and exclude patterns.
+// Errors are printed to stderr, and the rest of the files are still searched.
+func findFiles(args []string) []string {
+	paths := []string{}
+	for _, arg := range args {
+		matches := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(arg); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", arg, err)
+				continue
+			}
+			if len(matches) == 0 {
+				fmt.Fprintf(os.Stderr, "%s: no files match\n", arg)
+				continue
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+			if !info.IsDir() {
+				paths = append(paths, match)
+				continue
+			}
+
+			filepath.Walk(match, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return nil
+				}
+				if path != match && matchesAny(exclude, path) {
+					if info.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if info.Mode().IsRegular() && (len(include) == 0 || matchesAny(include, path)) {
+					paths = append(paths, path)
+				}
+				return nil
+			})
+		}
+	}
+	return paths
+}
+
+// matchesAny Check if the path or its base name matches any of the patterns
+func matchesAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, path); matched {
+			return true
+		}
+	}
+	return false
+}
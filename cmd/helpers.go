@@ -4,32 +4,176 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 
 	"github.com/vertoforce/go-ioc/ioc"
 )
 
+// sourceText The text IOCs were extracted from, if the command has it.  Used for report context.
+var sourceText string
+
 // printIOCHelper Helper to manage printing with provided flags
-func printIOCHelper(iocs []*ioc.IOC) {
+func printIOCHelper(iocs []*ioc.FoundIOC) {
+	if expandRedirects {
+		iocs = ioc.ExpandRedirects(iocs)
+	}
+	if normalize {
+		iocs = ioc.NormalizeIOCs(iocs)
+	}
 	if iocSort {
-		iocs = ioc.SortByType(iocs)
+		sort.SliceStable(iocs, func(i, j int) bool { return iocs[i].Type < iocs[j].Type })
 	}
 
+	// Get stats before changing how the IOCs are fanged
+	stats := formatStats(iocs)
+
 	if printFanged {
 		for i := range iocs {
 			iocs[i] = iocs[i].Fang()
 		}
 	}
 
-	if iocPrintStats {
-		fmt.Println("Stats:")
-		fmt.Println(ioc.PrintIOCsStats(iocs))
+	var output string
+	if statsOnly {
+		output = stats
+	} else {
+		if iocPrintStats {
+			fmt.Println("Stats:")
+			fmt.Println(stats)
+		}
+
+		var err error
+		output, err = formatIOCs(iocs)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
 	}
 
 	// Write to file if specified
 	if outputFile != "" {
-		ioutil.WriteFile(outputFile, []byte(ioc.PrintIOCs(iocs, iocPrintFormat)), os.ModePerm)
+		ioutil.WriteFile(outputFile, []byte(output), os.ModePerm)
 	} else {
-		fmt.Println(ioc.PrintIOCs(iocs, iocPrintFormat))
+		fmt.Println(output)
 	}
 
 }
+
+// standardizeFoundDefangs Standardize the defanged IOCs if the flag is set, like ioc.StandardizeDefangs
+func standardizeFoundDefangs(iocs []*ioc.FoundIOC) {
+	if !standardizeDefangs {
+		return
+	}
+	for i := range iocs {
+		iocs[i] = iocs[i].Fang().Defang()
+	}
+}
+
+// writeOutput Save the output to the output file if one was given, or print it
+func writeOutput(output string) {
+	if outputFile != "" {
+		ioutil.WriteFile(outputFile, []byte(output), os.ModePerm)
+	} else {
+		fmt.Print(output)
+	}
+}
+
+// formatStats Format the stats of the IOCs in the stats format
+func formatStats(iocs []*ioc.FoundIOC) string {
+	switch statsFormat {
+	case "json":
+		return ioc.PrintIOCsStatsJSON(iocs)
+	case "csv":
+		return ioc.PrintIOCsStatsCSV(iocs)
+	default:
+		return ioc.PrintIOCsStats(ioc.ToIOCs(iocs))
+	}
+}
+
+// formatIOCs Format IOCs with the template or print format, passing along any flags specific to that format
+func formatIOCs(found []*ioc.FoundIOC) (string, error) {
+	text := iocTemplate
+	if iocTemplateFile != "" {
+		contents, err := ioutil.ReadFile(iocTemplateFile)
+		if err != nil {
+			return "", err
+		}
+		text = string(contents)
+	}
+	if text != "" {
+		return ioc.PrintIOCsTemplate(found, text, !templateSet)
+	}
+
+	iocs := ioc.ToIOCs(found)
+	switch iocPrintFormat {
+	case "openioc":
+		return ioc.PrintIOCsOpenIOC(iocs, ioc.OpenIOCOptions{Author: author, Description: description})
+	case "suricata":
+		return ioc.PrintIOCsSuricata(iocs, ruleOptions())
+	case "snort":
+		return ioc.PrintIOCsSnort(iocs, ruleOptions())
+	case "yara":
+		source := title
+		if source == "" {
+			source = reference
+		}
+		return ioc.PrintIOCsYARA(iocs, ioc.YARAOptions{Source: source, Author: author, Description: description, Reference: reference})
+	case "sigma":
+		return ioc.PrintIOCsSigma(iocs, ioc.SigmaOptions{Title: title, Author: author, Description: description, Reference: reference}), nil
+	case "hosts":
+		return ioc.PrintIOCsHosts(iocs, blocklistOptions()), nil
+	case "rpz":
+		return ioc.PrintIOCsRPZ(iocs, blocklistOptions()), nil
+	case "dnsmasq":
+		return ioc.PrintIOCsDnsmasq(iocs, blocklistOptions()), nil
+	case "unbound":
+		return ioc.PrintIOCsUnbound(iocs, blocklistOptions()), nil
+	case "adblock":
+		return ioc.PrintIOCsAdblock(iocs, blocklistOptions()), nil
+	case "iptables":
+		return ioc.PrintIOCsIptables(iocs, ioc.FirewallOptions{Name: setName}), nil
+	case "nftables":
+		return ioc.PrintIOCsNftables(iocs, ioc.FirewallOptions{Name: setName}), nil
+	case "pf":
+		return ioc.PrintIOCsPf(iocs, ioc.FirewallOptions{Name: setName}), nil
+	case "cisco":
+		return ioc.PrintIOCsCiscoACL(iocs, ioc.FirewallOptions{Name: setName}), nil
+	case "zeek":
+		return ioc.PrintIOCsZeek(iocs, ioc.ZeekOptions{Source: title, Description: description, URL: reference}), nil
+	case "markdown":
+		return ioc.PrintIOCsMarkdown(iocs, reportOptions()), nil
+	case "html":
+		return ioc.PrintIOCsHTML(iocs, reportOptions()), nil
+	default:
+		return ioc.PrintIOCs(iocs, iocPrintFormat), nil
+	}
+}
+
+// ruleOptions Get the rule options from the flags
+func ruleOptions() ioc.RuleOptions {
+	return ioc.RuleOptions{
+		SIDStart:  sidStart,
+		SIDEnd:    sidEnd,
+		Classtype: classtype,
+		Message:   ruleMessage,
+		Reference: reference,
+	}
+}
+
+// blocklistOptions Get the DNS blocklist options from the flags
+func blocklistOptions() ioc.BlocklistOptions {
+	return ioc.BlocklistOptions{
+		Sinkhole:  sinkhole,
+		Allowlist: allowlist,
+		ZoneName:  zoneName,
+	}
+}
+
+// reportOptions Get the markdown and html report options from the flags
+func reportOptions() ioc.ReportOptions {
+	options := ioc.ReportOptions{Title: title, Source: reference, Fanged: printFanged}
+	if reportContext {
+		options.Text = sourceText
+	}
+	return options
+}
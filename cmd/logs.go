@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vertoforce/go-ioc/ioc"
+)
+
+var logsCommand = &cobra.Command{
+	Use:   "logs [log file...]",
+	Short: "Find IOCs in the fields of structured logs (JSON lines, CSV, key=value, Zeek, Suricata EVE, syslog), reading stdin if no files are given",
+	Long: "Each record is parsed, and only the values of its fields are searched, so the IOCs are not mixed up with the field names or other fields.  " +
+		"Zeek and Suricata EVE logs only have their known fields (ex: dns.rrname, fileinfo.sha256) turned in to IOCs of that type.  " +
+		"Use --fields and --exclude-fields to pick the fields with JSONPath-like selectors (ex: http.url, $.answers[*].data, $..src_ip) or CSV column names.  " +
+		"Every IOC has the record number and field path in its metadata, ex: -t '{{.IOC}} {{.Metadata.record}} {{.Metadata.field}}'",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		options := ioc.LogOptions{GetFangedIOCs: getFangedIOCs, Include: logFields, Exclude: logExcludeFields}
+		iocs := []*ioc.FoundIOC{}
+		if len(args) == 0 {
+			found, err := ioc.GetIOCsFromLogs(os.Stdin, logFormat, options)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			iocs = append(iocs, found...)
+		}
+		for _, path := range args {
+			file, err := os.Open(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+			found, err := ioc.GetIOCsFromLogs(file, logFormat, options)
+			file.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			}
+			for _, ioc := range found {
+				ioc.Source = path
+			}
+			iocs = append(iocs, found...)
+		}
+		standardizeFoundDefangs(iocs)
+		printIOCHelper(iocs)
+	},
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/vertoforce/go-ioc/ioc"
+)
+
+var parseCommand = &cobra.Command{
+	Use:   "parse [value...]",
+	Short: "Parse single indicators, printing their type, fanged and defanged forms, and validation, reading stdin lines if no values are given",
+	Long: "Each value is parsed as one IOC, and validated for its type: Bitcoin address checksums, the public suffix and registered domain of domains, emails, and URLs, and the class of IPs (public, private, loopback, etc).  " +
+		"URLs are split in to their scheme, host, port, path, file, decoded query parameters, and the URLs they redirect to (ex: ?url=).  " +
+		"A value is only valid if all of it is the IOC.  Use --json for output to script with.",
+	Example: "go-ioc parse 'hxxp://evil[.]com/a' 8.8.8.8 --json",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		values := args
+		if len(values) == 0 {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				if strings.TrimSpace(scanner.Text()) != "" {
+					values = append(values, scanner.Text())
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+
+		parsed := []*ioc.ParsedIOC{}
+		for _, value := range values {
+			parsed = append(parsed, ioc.Parse(value))
+		}
+		if parseJSON {
+			writeOutput(formatParsedJSON(parsed))
+			return
+		}
+		writeOutput(formatParsed(parsed))
+	},
+}
+
+// parsedJSON A parsed IOC, with its type name
+type parsedJSON struct {
+	Input    string            `json:"input"`
+	Type     string            `json:"type"`
+	IOC      string            `json:"ioc"`
+	Fanged   string            `json:"fanged"`
+	Defanged string            `json:"defanged"`
+	Valid    bool              `json:"valid"`
+	Checks   map[string]string `json:"checks"`
+	URL      *urlJSON          `json:"url,omitempty"`
+}
+
+// urlJSON The components of a URL, with the type name of its host
+type urlJSON struct {
+	Scheme    string              `json:"scheme"`
+	User      string              `json:"user,omitempty"`
+	Host      string              `json:"host"`
+	HostType  string              `json:"host_type"`
+	Port      string              `json:"port,omitempty"`
+	Path      string              `json:"path"`
+	File      string              `json:"file,omitempty"`
+	Extension string              `json:"extension,omitempty"`
+	Query     map[string][]string `json:"query,omitempty"`
+	Fragment  string              `json:"fragment,omitempty"`
+	Redirects []string            `json:"redirects,omitempty"`
+}
+
+// formatParsedJSON Format the parsed IOCs as a JSON array
+func formatParsedJSON(parsed []*ioc.ParsedIOC) string {
+	ret := []parsedJSON{}
+	for _, p := range parsed {
+		parsed := parsedJSON{p.Input, p.IOC.Type.String(), p.IOC.IOC, p.Fanged, p.Defanged, p.Valid, p.Checks, nil}
+		if u := p.URL; u != nil {
+			parsed.URL = &urlJSON{u.Scheme, u.User, u.Host, u.HostType.String(), u.Port, u.Path, u.File, u.Extension, u.Query, u.Fragment, u.Redirects}
+		}
+		ret = append(ret, parsed)
+	}
+	data, _ := json.MarshalIndent(ret, "", "  ")
+	return string(data) + "\n"
+}
+
+// formatParsed Format each parsed IOC as its input followed by indented fields
+func formatParsed(parsed []*ioc.ParsedIOC) string {
+	ret := new(strings.Builder)
+	for _, p := range parsed {
+		fmt.Fprintf(ret, "%s\n", p.Input)
+		fmt.Fprintf(ret, "  type: %s\n", p.IOC.Type)
+		fmt.Fprintf(ret, "  fanged: %s\n", p.Fanged)
+		fmt.Fprintf(ret, "  defanged: %s\n", p.Defanged)
+		fmt.Fprintf(ret, "  valid: %t\n", p.Valid)
+		checks := []string{}
+		for check := range p.Checks {
+			checks = append(checks, check)
+		}
+		sort.Strings(checks)
+		for _, check := range checks {
+			fmt.Fprintf(ret, "  %s: %s\n", check, p.Checks[check])
+		}
+		if p.URL != nil {
+			formatURLComponents(ret, p.URL)
+		}
+	}
+	return ret.String()
+}
+
+// formatURLComponents Write the components of a URL that it has, indented under the URL
+func formatURLComponents(ret *strings.Builder, u *ioc.URLComponents) {
+	fields := [][2]string{
+		{"scheme", u.Scheme}, {"user", u.User}, {"host", u.Host + " (" + u.HostType.String() + ")"}, {"port", u.Port},
+		{"path", u.Path}, {"file", u.File}, {"extension", u.Extension}, {"fragment", u.Fragment},
+	}
+	for _, field := range fields {
+		if field[1] != "" {
+			fmt.Fprintf(ret, "  url.%s: %s\n", field[0], field[1])
+		}
+	}
+	keys := []string{}
+	for key := range u.Query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range u.Query[key] {
+			fmt.Fprintf(ret, "  url.query.%s: %s\n", key, value)
+		}
+	}
+	for _, redirect := range u.Redirects {
+		fmt.Fprintf(ret, "  url.redirect: %s\n", redirect)
+	}
+}
@@ -5,18 +5,58 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/vertoforce/go-ioc/ioc"
 )
 
 var iocPrintFormat string
+var iocTemplate string
+var iocTemplateFile string
+var templateSet bool
 var outputFile string
 var iocTypes string
+var author string
+var description string
+var reference string
+var title string
+
+var sidStart int
+var sidEnd int
+var classtype string
+var ruleMessage string
+
+var sinkhole string
+var allowlist []string
+var zoneName string
+var setName string
+var reportContext bool
 
 var iocPrintStats bool
+var statsOnly bool
+var statsFormat string
 var iocSort bool
 
 var standardizeDefangs bool
 var printFanged bool
 var getFangedIOCs bool
+var normalize bool
+var expandRedirects bool
+
+var include []string
+var exclude []string
+var maxDepth int
+var maxSize int64
+var passwords []string
+var minStringLength int
+var decode bool
+var deobfuscate bool
+
+var emailHeaders bool
+
+var logFormat string
+var logFields []string
+var logExcludeFields []string
+
+var parseJSON bool
 
 var rootCmd = &cobra.Command{
 	Use:     "go-ioc [command]",
@@ -41,15 +81,66 @@ func init() {
 	// Commands
 	rootCmd.AddCommand(urlCommand)
 	rootCmd.AddCommand(rssCommand)
+	rootCmd.AddCommand(fileCommand)
+	rootCmd.AddCommand(emailCommand)
+	rootCmd.AddCommand(logsCommand)
+	rootCmd.AddCommand(defangCommand)
+	rootCmd.AddCommand(fangCommand)
+	rootCmd.AddCommand(parseCommand)
 	rootCmd.AddCommand(gendocsCommand)
 	rootCmd.AddCommand(stdinCommand)
 
 	// Root flags
-	rootCmd.PersistentFlags().StringVarP(&iocPrintFormat, "format", "f", "csv", "Print format for printing IOCs.  Options include: csv, table")
+	rootCmd.PersistentFlags().StringVarP(&iocPrintFormat, "format", "f", "csv", "Print format for printing IOCs.  Options include: csv, table, openioc, suricata, snort, yara, sigma, splunk, elastic-kql, elastic-eql, microsoft-kql, hosts, rpz, dnsmasq, unbound, adblock, cidr, iptables, nftables, pf, cisco, zeek, markdown, html")
+	rootCmd.PersistentFlags().StringVarP(&iocTemplate, "template", "t", "", "Go text/template to print each IOC with, overrides format.  Ex: '{{defang .}},{{typeName .}}'")
+	rootCmd.PersistentFlags().StringVar(&iocTemplateFile, "template-file", "", "File containing a Go text/template to print each IOC with, overrides template")
+	rootCmd.PersistentFlags().BoolVar(&templateSet, "template-set", false, "Execute the template once with the list of all IOCs instead of once per IOC")
 	rootCmd.PersistentFlags().StringVarP(&outputFile, "output", "o", "", "Save IOCs to file")
+	rootCmd.PersistentFlags().StringVar(&author, "author", "", "Author to include in formats with metadata (openioc, yara, sigma)")
+	rootCmd.PersistentFlags().StringVar(&description, "description", "", "Description to include in formats with metadata (openioc, yara, sigma, zeek)")
+	rootCmd.PersistentFlags().StringVar(&reference, "reference", "", "URL of the source article to reference in rules (suricata, snort, yara, sigma, zeek, markdown, html).  Defaults to the URL for the url command")
+	rootCmd.PersistentFlags().StringVar(&title, "title", "", "Title of the source report, used to name rules (yara, sigma), as the source (zeek), and as the title (markdown, html).  Defaults to the reference for yara")
+	rootCmd.PersistentFlags().IntVar(&sidStart, "sidStart", 1000000, "First sid to use for rules (suricata, snort)")
+	rootCmd.PersistentFlags().IntVar(&sidEnd, "sidEnd", 0, "Last sid that can be used for rules, 0 for no limit (suricata, snort)")
+	rootCmd.PersistentFlags().StringVar(&classtype, "classtype", "trojan-activity", "Classtype of rules (suricata, snort)")
+	rootCmd.PersistentFlags().StringVar(&ruleMessage, "ruleMessage", "go-ioc {type} {ioc}", "Template for the msg of rules, {type} and {ioc} are replaced (suricata, snort)")
+	rootCmd.PersistentFlags().StringVar(&sinkhole, "sinkhole", "0.0.0.0", "Address blocked domains resolve to (hosts, dnsmasq, unbound)")
+	rootCmd.PersistentFlags().StringSliceVar(&allowlist, "allowlist", nil, "Domains (and their subdomains) to never block in hosts, rpz, dnsmasq, unbound, and adblock, their parent domains are not blocked either except in hosts")
+	rootCmd.PersistentFlags().StringVar(&zoneName, "zone", "rpz.local", "Name of the response policy zone (rpz)")
+	rootCmd.PersistentFlags().StringVar(&setName, "setName", "go-ioc", "Name of the set, table, or ACL (iptables, nftables, pf, cisco)")
+	rootCmd.PersistentFlags().BoolVar(&reportContext, "context", false, "Include a snippet of the text around each IOC, when the command has the text (markdown, html)")
 	rootCmd.PersistentFlags().BoolVar(&iocPrintStats, "stats", false, "Print count of each IOC found at start of output")
+	rootCmd.PersistentFlags().BoolVar(&statsOnly, "stats-only", false, "Print only the stats, instead of the IOCs")
+	rootCmd.PersistentFlags().StringVar(&statsFormat, "stats-format", "text", "Format of the stats (text, json, csv).  json and csv include fanged/defanged and per source counts")
 	rootCmd.PersistentFlags().BoolVarP(&iocSort, "sort", "s", true, "Sort IOCs by their type")
 	rootCmd.PersistentFlags().BoolVar(&standardizeDefangs, "standardizeDefangs", true, "Standardize all defanged IOCs using square brackets")
 	rootCmd.PersistentFlags().BoolVar(&printFanged, "printFanged", false, "Print all IOCs fanged, will override standardizeDefangs")
 	rootCmd.PersistentFlags().BoolVar(&getFangedIOCs, "all", false, "Get all fanged IOCs.  This typically is rather noisy in that it finds _all_ links, etc")
+	rootCmd.PersistentFlags().BoolVar(&expandRedirects, "redirects", false, "Also print the URLs that URLs redirect to in their query parameters (ex: ?url=), and their hosts, with the URL they were in as their metadata")
+	rootCmd.PersistentFlags().BoolVar(&normalize, "normalize", false, "Normalize IOCs (lowercase domains and hashes, remove trailing dots and default ports, uppercase CVE IDs, etc) and remove the duplicates")
+
+	// File flags
+	fileCommand.Flags().StringSliceVar(&include, "include", nil, "Only search files in directories whose name or path match one of these globs, ex: '*.txt'")
+	fileCommand.Flags().StringSliceVar(&exclude, "exclude", nil, "Skip files and directories whose name or path match one of these globs, ex: '.git'")
+	fileCommand.Flags().IntVar(&maxDepth, "max-depth", 4, "Most layers of nested archives, compression, and emails to open")
+	fileCommand.Flags().Int64Var(&maxSize, "max-size", 256<<20, "Most bytes to read and decompress from each file")
+	fileCommand.Flags().StringSliceVar(&passwords, "password", ioc.DefaultPasswords, "Passwords to try on encrypted zips")
+	fileCommand.Flags().IntVar(&minStringLength, "min-length", 4, "Shortest ASCII or UTF-16 string to search in binary files")
+	fileCommand.Flags().BoolVar(&decode, "decode", false, "Also search base64, hex, and URL encoded text, labeling the IOCs found with the encodings in their metadata")
+	fileCommand.Flags().BoolVar(&deobfuscate, "deobfuscate", false, "Also search JavaScript and PowerShell after resolving string concatenation, char codes, -join, and backtick escapes")
+
+	// Stdin flags
+	stdinCommand.Flags().BoolVar(&decode, "decode", false, "Also search base64, hex, and URL encoded text, labeling the IOCs found with the encodings in their metadata")
+	stdinCommand.Flags().BoolVar(&deobfuscate, "deobfuscate", false, "Also search JavaScript and PowerShell after resolving string concatenation, char codes, -join, and backtick escapes")
+
+	// Email flags
+	emailCommand.Flags().BoolVar(&emailHeaders, "headers", false, "Only print the indicators in the headers")
+
+	// Logs flags
+	logsCommand.Flags().StringVar(&logFormat, "log-format", "", "Format of the logs (json, csv, kv, zeek, eve, syslog), detected from the first line if empty")
+	logsCommand.Flags().StringSliceVar(&logFields, "fields", nil, "Only search the fields matching these selectors, ex: 'http.url,$..src_ip'")
+	logsCommand.Flags().StringSliceVar(&logExcludeFields, "exclude-fields", nil, "Skip the fields matching these selectors")
+
+	// Parse flags
+	parseCommand.Flags().BoolVar(&parseJSON, "json", false, "Print the results as a JSON array")
 }
@@ -20,6 +20,6 @@ var rssCommand = &cobra.Command{
 		if err != nil {
 			fmt.Println(err)
 		}
-		printIOCHelper(iocs)
+		printIOCHelper(ioc.FoundIOCs(iocs, ""))
 	},
 }
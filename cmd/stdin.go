@@ -18,10 +18,13 @@ var stdinCommand = &cobra.Command{
 		if err != nil {
 			fmt.Println(err)
 		}
-		iocs := ioc.GetIOCs(string(stdin), getFangedIOCs)
-		if standardizeDefangs {
-			ioc.StandardizeDefangs(iocs)
-		}
+		sourceText = string(stdin)
+		iocs := ioc.GetIOCsFromText(sourceText, ioc.ContentOptions{
+			GetFangedIOCs: getFangedIOCs,
+			Decode:        decode,
+			Deobfuscate:   deobfuscate,
+		})
+		standardizeFoundDefangs(iocs)
 		printIOCHelper(iocs)
 	},
 }
@@ -11,7 +11,7 @@ import (
 var urlCommand = &cobra.Command{
 	Use:   "url [URL]",
 	Short: "Crawl a URL and print all the IOCs",
-	Long:  "This command will only look for IOCs in the `text` of the page.  This means all the `href`s and other html tag data will not be included.",
+	Long:  "This command will only look for IOCs in the `text` of the page.  This means all the `href`s and other html tag data will not be included.  PDFs are detected and have the IOCs in their text printed.",
 	Args:  cobra.ExactArgs(1),
 
 	Run: func(cmd *cobra.Command, args []string) {
@@ -22,10 +22,13 @@ var urlCommand = &cobra.Command{
 			return
 		}
 		req = req.WithContext(cmd.Context())
+		if reference == "" {
+			reference = url
+		}
 		iocs, err := ioc.GetIOCsFromURLPage(req)
 		if err != nil {
 			fmt.Println(err)
 		}
-		printIOCHelper(iocs)
+		printIOCHelper(ioc.FoundIOCs(iocs, url))
 	},
 }
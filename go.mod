@@ -9,7 +9,8 @@ require (
 	github.com/mpvl/unique v0.0.0-20150818121801-cbe035fff7de
 	github.com/spf13/cobra v0.0.6
 	github.com/stretchr/testify v1.7.0
+	github.com/ulikunitz/xz v0.5.17
 	github.com/vertoforce/multiregex v0.0.0-20200305221808-10dce2b47221
-	golang.org/x/net v0.0.0-20200301022130-244492dfa37a // indirect
+	golang.org/x/net v0.0.0-20200301022130-244492dfa37a
 	golang.org/x/text v0.3.2 // indirect
 )
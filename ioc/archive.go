@@ -0,0 +1,221 @@
+package ioc
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+)
+
+// setMetadata Set a Metadata value of the IOC, copying the map first as IOCs found together share it
+func setMetadata(ioc *FoundIOC, key, value string) {
+	metadata := map[string]string{key: value}
+	for k, v := range ioc.Metadata {
+		if k != key {
+			metadata[k] = v
+		}
+	}
+	ioc.Metadata = metadata
+}
+
+// extractMember Get the IOCs from the name and content of an archive member, setting their "member" Metadata.
+// IOCs from archives inside the member have the full path, ex: outer.zip/inner.txt
+func (e *contentExtractor) extractMember(name string, data []byte, depth int) ([]*FoundIOC, error) {
+	iocs, err := e.extract(bytes.NewReader(data), int64(len(data)), depth+1)
+	iocs = append(FoundIOCs(GetIOCs(name, e.options.GetFangedIOCs), ""), iocs...)
+	for _, ioc := range iocs {
+		if inner := ioc.Metadata["member"]; inner != "" {
+			setMetadata(ioc, "member", name+"/"+inner)
+		} else {
+			setMetadata(ioc, "member", name)
+		}
+	}
+	return iocs, err
+}
+
+// archiveErrors The errors from the members of an archive, so the IOCs from the others can still be returned
+type archiveErrors []string
+
+func (errs *archiveErrors) add(name string, err error) {
+	*errs = append(*errs, fmt.Sprintf("%s: %s", name, err))
+}
+
+func (errs archiveErrors) err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(errs, "; "))
+}
+
+func (e *contentExtractor) extractZip(reader io.ReaderAt, size int64, depth int) ([]*FoundIOC, error) {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, err
+	}
+
+	iocs := []*FoundIOC{}
+	errs := archiveErrors{}
+	for _, file := range zipReader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		var content []byte
+		if file.Flags&1 != 0 {
+			content, err = e.readEncryptedZipFile(reader, size, file)
+		} else {
+			var reader io.ReadCloser
+			reader, err = file.Open()
+			if err == nil {
+				content, err = e.read(reader)
+				reader.Close()
+			}
+		}
+		if err != nil {
+			errs.add(file.Name, err)
+			if e.decompressed > e.options.MaxSize {
+				break
+			}
+			continue
+		}
+
+		memberIOCs, err := e.extractMember(file.Name, content, depth)
+		if err != nil {
+			errs.add(file.Name, err)
+		}
+		iocs = appendUniqueIOCs(iocs, memberIOCs...)
+	}
+	return iocs, errs.err()
+}
+
+func (e *contentExtractor) extractTar(reader io.Reader, depth int) ([]*FoundIOC, error) {
+	tarReader := tar.NewReader(reader)
+	iocs := []*FoundIOC{}
+	errs := archiveErrors{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs.add("tar", err)
+			break
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		content, err := e.read(tarReader)
+		if err != nil {
+			errs.add(header.Name, err)
+			if e.decompressed > e.options.MaxSize {
+				break
+			}
+			continue
+		}
+		memberIOCs, err := e.extractMember(header.Name, content, depth)
+		if err != nil {
+			errs.add(header.Name, err)
+		}
+		iocs = appendUniqueIOCs(iocs, memberIOCs...)
+	}
+	return iocs, errs.err()
+}
+
+// readEncryptedZipFile Decrypt a zip member encrypted with traditional PKWARE encryption (ZipCrypto), trying each password
+func (e *contentExtractor) readEncryptedZipFile(zipReader io.ReaderAt, size int64, file *zip.File) ([]byte, error) {
+	if file.Method == 99 {
+		return nil, fmt.Errorf("AES encrypted zips are not supported")
+	}
+	offset, err := file.DataOffset()
+	if err != nil {
+		return nil, err
+	}
+	if offset+int64(file.CompressedSize64) > size || file.CompressedSize64 < 12 {
+		return nil, zip.ErrFormat
+	}
+
+	// The last byte of the encryption header is checked against the CRC, or the time if the CRC comes after the data
+	check := byte(file.CRC32 >> 24)
+	if file.Flags&0x8 != 0 {
+		check = byte(file.ModifiedTime >> 8)
+	}
+
+	for _, password := range e.options.Passwords {
+		decrypted := &zipCryptoReader{newZipCrypto(password), io.NewSectionReader(zipReader, offset, int64(file.CompressedSize64))}
+		header := make([]byte, 12)
+		if _, err := io.ReadFull(decrypted, header); err != nil {
+			return nil, err
+		}
+		if header[11] != check {
+			continue
+		}
+
+		var reader io.Reader
+		switch file.Method {
+		case zip.Store:
+			reader = decrypted
+		case zip.Deflate:
+			reader = flate.NewReader(decrypted)
+		default:
+			return nil, zip.ErrAlgorithm
+		}
+		content, err := e.read(reader)
+		if err != nil {
+			// A wrong password can pass the header check, and then fail to decompress
+			if e.decompressed > e.options.MaxSize {
+				return nil, err
+			}
+			continue
+		}
+		if crc32.ChecksumIEEE(content) == file.CRC32 {
+			return content, nil
+		}
+	}
+	return nil, fmt.Errorf("encrypted, and none of the passwords worked")
+}
+
+// zipCrypto The keys of traditional PKWARE encryption
+type zipCrypto [3]uint32
+
+func newZipCrypto(password string) *zipCrypto {
+	keys := &zipCrypto{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		keys.update(password[i])
+	}
+	return keys
+}
+
+func (keys *zipCrypto) update(b byte) {
+	keys[0] = crc32Update(keys[0], b)
+	keys[1] = (keys[1]+keys[0]&0xff)*134775813 + 1
+	keys[2] = crc32Update(keys[2], byte(keys[1]>>24))
+}
+
+func (keys *zipCrypto) stream() byte {
+	temp := keys[2] | 2
+	return byte((temp * (temp ^ 1)) >> 8)
+}
+
+// zipCryptoReader Decrypt the data read from the reader with the keys
+type zipCryptoReader struct {
+	keys   *zipCrypto
+	reader io.Reader
+}
+
+func (r *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	for i := 0; i < n; i++ {
+		p[i] ^= r.keys.stream()
+		r.keys.update(p[i])
+	}
+	return n, err
+}
+
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ crc>>8
+}
@@ -0,0 +1,171 @@
+package ioc
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+)
+
+func buildTar(files [][2]string) []byte {
+	ret := new(bytes.Buffer)
+	w := tar.NewWriter(ret)
+	for _, file := range files {
+		w.WriteHeader(&tar.Header{Name: file[0], Mode: 0644, Size: int64(len(file[1])), Typeflag: tar.TypeReg})
+		w.Write([]byte(file[1]))
+	}
+	w.Close()
+	return ret.Bytes()
+}
+
+func xzBytes(data []byte) []byte {
+	ret := new(bytes.Buffer)
+	w, _ := xz.NewWriter(ret)
+	w.Write(data)
+	w.Close()
+	return ret.Bytes()
+}
+
+// buildEncryptedZip Build a zip of one stored file, encrypted with ZipCrypto
+func buildEncryptedZip(name, content, password string) []byte {
+	crc := crc32.ChecksumIEEE([]byte(content))
+	plain := append([]byte("random head"), byte(crc>>24))
+	plain = append(plain, content...)
+	keys := newZipCrypto(password)
+	encrypted := make([]byte, len(plain))
+	for i, b := range plain {
+		encrypted[i] = b ^ keys.stream()
+		keys.update(b)
+	}
+
+	ret := new(bytes.Buffer)
+	write := func(values ...interface{}) {
+		for _, value := range values {
+			binary.Write(ret, binary.LittleEndian, value)
+		}
+	}
+	// Local file header, data, central directory, and end of central directory
+	write(uint32(0x04034b50), uint16(20), uint16(1), uint16(0), uint32(0), crc, uint32(len(encrypted)), uint32(len(content)), uint16(len(name)), uint16(0))
+	ret.WriteString(name)
+	ret.Write(encrypted)
+	directory := ret.Len()
+	write(uint32(0x02014b50), uint16(20), uint16(20), uint16(1), uint16(0), uint32(0), crc, uint32(len(encrypted)), uint32(len(content)), uint16(len(name)), uint16(0), uint16(0), uint16(0), uint16(0), uint32(0), uint32(0))
+	ret.WriteString(name)
+	write(uint32(0x06054b50), uint16(0), uint16(0), uint16(1), uint16(1), uint32(ret.Len()-directory), uint32(directory), uint16(0))
+	return ret.Bytes()
+}
+
+func TestGetIOCsFromArchive(t *testing.T) {
+	member := func(path string) map[string]string {
+		return map[string]string{"member": path}
+	}
+	tests := []struct {
+		name    string
+		input   []byte
+		options ContentOptions
+		want    []*FoundIOC
+	}{
+		{
+			"zip",
+			buildZip([][2]string{{"notes", "evil[.]com"}, {"dir/", ""}, {"nested", string(buildZip([][2]string{{"inner/notes", "1[.]2[.]3[.]4"}}))}}),
+			ContentOptions{},
+			[]*FoundIOC{
+				{IOC: "evil[.]com", Type: Domain, Metadata: member("notes")},
+				{IOC: "1[.]2[.]3[.]4", Type: IPv4, Metadata: member("nested/inner/notes")},
+			},
+		},
+		{
+			"tar.gz",
+			gzipBytes(buildTar([][2]string{{"notes", "evil[.]com"}, {"more", "1[.]2[.]3[.]4"}})),
+			ContentOptions{},
+			[]*FoundIOC{
+				{IOC: "evil[.]com", Type: Domain, Metadata: member("notes")},
+				{IOC: "1[.]2[.]3[.]4", Type: IPv4, Metadata: member("more")},
+			},
+		},
+		{
+			"xz",
+			xzBytes([]byte("visit evil[.]com")),
+			ContentOptions{},
+			[]*FoundIOC{{IOC: "evil[.]com", Type: Domain}},
+		},
+		{
+			"encrypted zip",
+			buildEncryptedZip("sample", "calls back to evil[.]com", "infected"),
+			ContentOptions{},
+			[]*FoundIOC{{IOC: "evil[.]com", Type: Domain, Metadata: member("sample")}},
+		},
+		{
+			"encrypted zip with other passwords",
+			buildEncryptedZip("sample", "calls back to evil[.]com", "secret"),
+			ContentOptions{Passwords: []string{"infected", "secret"}},
+			[]*FoundIOC{{IOC: "evil[.]com", Type: Domain, Metadata: member("sample")}},
+		},
+	}
+
+	for _, test := range tests {
+		if test.name == "zip" || test.name == "encrypted zip" {
+			if got := DetectContentType(test.input); got != ContentZip {
+				t.Errorf("%s: detected as %s", test.name, got)
+			}
+		}
+		got, err := GetIOCsFromContentWithOptions(test.input, test.options)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		sort.SliceStable(got, func(i, j int) bool { return got[i].Type < got[j].Type })
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, wanted %v", test.name, got, test.want)
+		}
+	}
+
+	// Errors still return the IOCs from the other members
+	data := buildZip([][2]string{{"notes", "evil[.]com"}, {"sample", string(buildEncryptedZip("sample", "1[.]2[.]3[.]4", "secret"))}})
+	got, err := GetIOCsFromContent(data, false)
+	if err == nil {
+		t.Errorf("Should have errored on the wrong password")
+	}
+	if want := []*FoundIOC{{IOC: "evil[.]com", Type: Domain, Metadata: member("notes")}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+
+	data = gzipBytes(bytes.Repeat([]byte("evil[.]com "), 1000))
+	if _, err := GetIOCsFromContentWithOptions(data, ContentOptions{MaxSize: 1000}); err == nil {
+		t.Errorf("Should have errored on decompressing more than the max size")
+	}
+	data = buildZip([][2]string{{"nested", string(buildZip([][2]string{{"notes", "evil[.]com"}}))}})
+	if _, err := GetIOCsFromContentWithOptions(data, ContentOptions{MaxDepth: 1}); err == nil {
+		t.Errorf("Should have errored on too many nested archives")
+	}
+
+	// Emails share the depth with archives: zip > eml > attached zip > notes
+	attachment := "Content-Type: application/zip; name=\"inner.zip\"\r\nContent-Transfer-Encoding: base64\r\n\r\n" +
+		base64.StdEncoding.EncodeToString(buildZip([][2]string{{"notes", "evil[.]com"}}))
+	email := "From: a@example.com\r\nTo: b@example.com\r\nSubject: fwd\r\n" + attachment
+	data = buildZip([][2]string{{"mail.eml", email}})
+	if _, err := GetIOCsFromContentWithOptions(data, ContentOptions{MaxDepth: 2}); err == nil {
+		t.Errorf("Should have errored on an archive in an email in an archive over the depth limit")
+	}
+	got, err = GetIOCsFromContentWithOptions(data, ContentOptions{MaxDepth: 3})
+	if err != nil || !containsFoundIOC(got, &IOC{IOC: "evil[.]com", Type: Domain}) {
+		t.Errorf("got %v %v, wanted the IOC in the attached archive", got, err)
+	}
+
+	// Attached emails count as a layer
+	attached := "From: c@example.com\r\nTo: b@example.com\r\nSubject: inner\r\n\r\nc2 at 1[.]2[.]3[.]4"
+	email = "From: a@example.com\r\nTo: b@example.com\r\nSubject: fwd\r\nContent-Type: message/rfc822\r\n\r\n" + attached
+	if _, err := GetIOCsFromContentWithOptions([]byte(email), ContentOptions{MaxDepth: 1}); err == nil {
+		t.Errorf("Should have errored on an attached email over the depth limit")
+	}
+	got, err = GetIOCsFromContentWithOptions([]byte(email), ContentOptions{MaxDepth: 2})
+	if err != nil || !containsFoundIOC(got, &IOC{IOC: "1[.]2[.]3[.]4", Type: IPv4}) {
+		t.Errorf("got %v %v, wanted the IOC in the attached email", got, err)
+	}
+}
@@ -0,0 +1,377 @@
+package ioc
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"debug/elf"
+	"debug/pe"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// defaultMinStringLength Shortest string extracted from binaries by default, the same as the strings command
+const defaultMinStringLength = 4
+
+const (
+	// maxPEImports Most imported DLLs and functions read, to stop on corrupt import tables
+	maxPEImports = 1 << 16
+)
+
+// ExtractStrings Get the runs of printable ASCII, and printable ASCII encoded as UTF-16LE, that are at least minLength characters long
+func ExtractStrings(data []byte, minLength int) []string {
+	strings, _ := extractStrings(bytes.NewReader(data), int64(len(data)), minLength)
+	return strings
+}
+
+// extractStrings Get the strings of the content, see ExtractStrings.  The content is read once for each encoding, rather than held in memory.
+func extractStrings(reader io.ReaderAt, size int64, minLength int) ([]string, error) {
+	if minLength < 1 {
+		minLength = 1
+	}
+	isPrintable := func(b byte) bool {
+		return b >= 0x20 && b < 0x7f || b == '\t'
+	}
+
+	ret := []string{}
+	current := new(strings.Builder)
+	flush := func() {
+		if current.Len() >= minLength {
+			ret = append(ret, current.String())
+		}
+		current.Reset()
+	}
+
+	// ASCII
+	content := bufio.NewReader(io.NewSectionReader(reader, 0, size))
+	for {
+		b, err := content.ReadByte()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return ret, err
+		}
+		if isPrintable(b) {
+			current.WriteByte(b)
+		} else {
+			flush()
+		}
+	}
+	flush()
+
+	// UTF-16LE, starting at both even and odd offsets
+	for start := int64(0); start < 2 && start < size; start++ {
+		content := bufio.NewReader(io.NewSectionReader(reader, start, size-start))
+		char := make([]byte, 2)
+		for {
+			if _, err := io.ReadFull(content, char); err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			} else if err != nil {
+				return ret, err
+			}
+			if char[1] == 0 && isPrintable(char[0]) {
+				current.WriteByte(char[0])
+			} else {
+				flush()
+			}
+		}
+		flush()
+	}
+	return ret, nil
+}
+
+// GetIOCsFromBinary Get the IOCs from a binary file (executables, documents, dumps, etc).
+// The MD5, SHA1, and SHA256 of PE and ELF files are included, with "file" as their "binary" Metadata.
+// Other binary files (images, attachments, blobs) are not hashed, since their hashes are rarely IOCs.
+// IOCs are searched for in the ASCII and UTF-16LE strings of options.MinStringLength or more characters.
+// PE files also have their imported DLLs included, and ELF files their needed libraries, with "import" as their "binary" Metadata.
+// The format, the MD5 of each section (ex: section..text.md5), and for PE files the compile time and imphash,
+// are added to the Metadata of the file hashes, since they are not hashes of files.
+func GetIOCsFromBinary(data []byte, options ContentOptions) ([]*FoundIOC, error) {
+	return getIOCsFromBinary(bytes.NewReader(data), int64(len(data)), options)
+}
+
+// getIOCsFromBinary Get the IOCs from a binary file, see GetIOCsFromBinary.  The file is read as it is needed, rather than held in memory.
+func getIOCsFromBinary(reader io.ReaderAt, size int64, options ContentOptions) ([]*FoundIOC, error) {
+	if options.MinStringLength == 0 {
+		options.MinStringLength = defaultMinStringLength
+	}
+
+	iocs := []*FoundIOC{}
+	var err error
+	switch {
+	case isPE(reader, size):
+		fileMetadata := map[string]string{"binary": "file", "format": "pe"}
+		if iocs, err = fileHashes(reader, size, fileMetadata); err != nil {
+			return nil, err
+		}
+		var metadataIOCs []*FoundIOC
+		metadataIOCs, err = getPEIOCs(reader, fileMetadata)
+		iocs = append(iocs, metadataIOCs...)
+		if err != nil {
+			err = fmt.Errorf("pe: %s", err)
+		}
+	case readsPrefix(reader, 0, []byte(elf.ELFMAG)):
+		fileMetadata := map[string]string{"binary": "file", "format": "elf"}
+		if iocs, err = fileHashes(reader, size, fileMetadata); err != nil {
+			return nil, err
+		}
+		var metadataIOCs []*FoundIOC
+		metadataIOCs, err = getELFIOCs(reader, fileMetadata)
+		iocs = append(iocs, metadataIOCs...)
+		if err != nil {
+			err = fmt.Errorf("elf: %s", err)
+		}
+	}
+
+	binaryStrings, stringsErr := extractStrings(reader, size, options.MinStringLength)
+	if stringsErr != nil {
+		return iocs, stringsErr
+	}
+	for _, ioc := range GetIOCs(strings.Join(binaryStrings, "\n"), options.GetFangedIOCs) {
+		if !containsFoundIOC(iocs, ioc) {
+			iocs = append(iocs, &FoundIOC{IOC: ioc.IOC, Type: ioc.Type})
+		}
+	}
+	return iocs, err
+}
+
+// fileHashes Get the MD5, SHA1, and SHA256 of the file, sharing the metadata
+func fileHashes(reader io.ReaderAt, size int64, metadata map[string]string) ([]*FoundIOC, error) {
+	md5Hash, sha1Hash, sha256Hash := md5.New(), sha1.New(), sha256.New()
+	if _, err := io.Copy(io.MultiWriter(md5Hash, sha1Hash, sha256Hash), io.NewSectionReader(reader, 0, size)); err != nil {
+		return nil, err
+	}
+	return []*FoundIOC{
+		{IOC: hex.EncodeToString(md5Hash.Sum(nil)), Type: MD5, Metadata: metadata},
+		{IOC: hex.EncodeToString(sha1Hash.Sum(nil)), Type: SHA1, Metadata: metadata},
+		{IOC: hex.EncodeToString(sha256Hash.Sum(nil)), Type: SHA256, Metadata: metadata},
+	}, nil
+}
+
+// readsPrefix Check if the bytes at the offset are the prefix
+func readsPrefix(reader io.ReaderAt, offset int64, prefix []byte) bool {
+	value := make([]byte, len(prefix))
+	n, _ := reader.ReadAt(value, offset)
+	return n == len(prefix) && bytes.Equal(value, prefix)
+}
+
+// isPE Check if the file is a PE file, with an MZ header pointing to a PE header
+func isPE(reader io.ReaderAt, size int64) bool {
+	if size < 0x40 || !readsPrefix(reader, 0, []byte("MZ")) {
+		return false
+	}
+	offset := make([]byte, 4)
+	if _, err := reader.ReadAt(offset, 0x3c); err != nil {
+		return false
+	}
+	return readsPrefix(reader, int64(binary.LittleEndian.Uint32(offset)), []byte("PE\x00\x00"))
+}
+
+// getPEIOCs Get the imported DLLs of a PE file, adding its compile time, imphash, and section hashes to the metadata
+func getPEIOCs(reader io.ReaderAt, metadata map[string]string) ([]*FoundIOC, error) {
+	file, err := pe.NewFile(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	metadata["compile_time"] = time.Unix(int64(file.FileHeader.TimeDateStamp), 0).UTC().Format(time.RFC3339)
+
+	iocs := []*FoundIOC{}
+	imports, err := peImports(file)
+	if len(imports) > 0 {
+		metadata["imphash"] = imphash(imports)
+	}
+	sectionHashes(peSections(file), metadata)
+
+	seen := map[string]bool{}
+	for _, imported := range imports {
+		if !seen[strings.ToLower(imported[0])] {
+			seen[strings.ToLower(imported[0])] = true
+			iocs = append(iocs, &FoundIOC{IOC: imported[0], Type: File, Metadata: map[string]string{"binary": "import"}})
+		}
+	}
+	return iocs, err
+}
+
+// peImports Read the import table of a PE file, returning the DLL and function name (or ord<N> for imports by ordinal) of each import
+func peImports(file *pe.File) ([][2]string, error) {
+	var directory pe.DataDirectory
+	is64 := false
+	switch header := file.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		if header.NumberOfRvaAndSizes > 1 {
+			directory = header.DataDirectory[1]
+		}
+	case *pe.OptionalHeader64:
+		if header.NumberOfRvaAndSizes > 1 {
+			directory = header.DataDirectory[1]
+		}
+		is64 = true
+	}
+	if directory.VirtualAddress == 0 {
+		return nil, nil
+	}
+
+	// sectionData Read up to size bytes from a virtual address, stopping at the end of its section
+	sectionData := func(address uint32, size int) []byte {
+		for _, section := range file.Sections {
+			if address >= section.VirtualAddress && address < section.VirtualAddress+section.Size {
+				offset := address - section.VirtualAddress
+				value := make([]byte, minInt(size, int(section.Size-offset)))
+				n, _ := section.ReadAt(value, int64(offset))
+				return value[:n]
+			}
+		}
+		return nil
+	}
+	read := func(address uint32, size int) []byte {
+		if value := sectionData(address, size); len(value) == size {
+			return value
+		}
+		return nil
+	}
+	readString := func(address uint32) string {
+		value := sectionData(address, 512)
+		if end := bytes.IndexByte(value, 0); end != -1 {
+			value = value[:end]
+		}
+		return string(value)
+	}
+
+	imports := [][2]string{}
+	for descriptor := directory.VirtualAddress; ; descriptor += 20 {
+		entry := read(descriptor, 20)
+		if entry == nil {
+			return imports, fmt.Errorf("import table is outside of the sections")
+		}
+		lookup, name, thunks := binary.LittleEndian.Uint32(entry), binary.LittleEndian.Uint32(entry[12:]), binary.LittleEndian.Uint32(entry[16:])
+		if name == 0 && thunks == 0 {
+			return imports, nil
+		}
+		if lookup == 0 {
+			lookup = thunks
+		}
+		dll := readString(name)
+
+		thunkSize := uint32(4)
+		if is64 {
+			thunkSize = 8
+		}
+		for thunk := lookup; ; thunk += thunkSize {
+			if len(imports) >= maxPEImports {
+				return imports, fmt.Errorf("more than %d imports", maxPEImports)
+			}
+			value := read(thunk, int(thunkSize))
+			if value == nil {
+				return imports, fmt.Errorf("import lookup table of %s is outside of the sections", dll)
+			}
+			var ordinal bool
+			var address uint64
+			if is64 {
+				address = binary.LittleEndian.Uint64(value)
+				ordinal = address&(1<<63) != 0
+			} else {
+				address = uint64(binary.LittleEndian.Uint32(value))
+				ordinal = address&(1<<31) != 0
+			}
+			if address == 0 {
+				break
+			}
+
+			if ordinal {
+				imports = append(imports, [2]string{dll, fmt.Sprintf("ord%d", address&0xffff)})
+			} else {
+				// Skip the hint before the name
+				imports = append(imports, [2]string{dll, readString(uint32(address) + 2)})
+			}
+		}
+	}
+}
+
+// imphash Get the import hash of a PE file, the MD5 of its imports in order as lowercase dll.function, the same as pefile.
+// Imports by ordinal are ord<N>, pefile also resolves the ordinals of ws2_32, wsock32, and oleaut32 to their names.
+func imphash(imports [][2]string) string {
+	names := []string{}
+	for _, imported := range imports {
+		dll := strings.ToLower(imported[0])
+		if dot := strings.LastIndex(dll, "."); dot != -1 {
+			switch dll[dot+1:] {
+			case "dll", "ocx", "sys":
+				dll = dll[:dot]
+			}
+		}
+		names = append(names, dll+"."+strings.ToLower(imported[1]))
+	}
+	sum := md5.Sum([]byte(strings.Join(names, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// binarySection A section of a PE or ELF file
+type binarySection struct {
+	name string
+	open func() io.ReadSeeker
+}
+
+func peSections(file *pe.File) []binarySection {
+	sections := []binarySection{}
+	for _, section := range file.Sections {
+		if section.Size > 0 {
+			sections = append(sections, binarySection{section.Name, section.Open})
+		}
+	}
+	return sections
+}
+
+// sectionHashes Add the MD5 of each section that can be read to the metadata, as section.<name>.md5
+func sectionHashes(sections []binarySection, metadata map[string]string) {
+	for _, section := range sections {
+		hash := md5.New()
+		if _, err := io.Copy(hash, section.open()); err != nil {
+			continue
+		}
+		metadata["section."+section.name+".md5"] = hex.EncodeToString(hash.Sum(nil))
+	}
+}
+
+// getELFIOCs Get the needed libraries of an ELF file, adding its machine, type, interpreter, and section hashes to the metadata
+func getELFIOCs(reader io.ReaderAt, metadata map[string]string) ([]*FoundIOC, error) {
+	file, err := elf.NewFile(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	metadata["machine"] = file.Machine.String()
+	metadata["type"] = file.Type.String()
+	for _, prog := range file.Progs {
+		if prog.Type == elf.PT_INTERP {
+			if interpreter, err := ioutil.ReadAll(prog.Open()); err == nil {
+				metadata["interpreter"] = strings.TrimRight(string(interpreter), "\x00")
+			}
+		}
+	}
+
+	sections := []binarySection{}
+	for _, section := range file.Sections {
+		if section.Type != elf.SHT_NOBITS && section.Type != elf.SHT_NULL && section.Size > 0 {
+			sections = append(sections, binarySection{section.Name, section.Open})
+		}
+	}
+	sectionHashes(sections, metadata)
+
+	iocs := []*FoundIOC{}
+
+	// Statically linked files have no dynamic section
+	libraries, _ := file.ImportedLibraries()
+	for _, library := range libraries {
+		iocs = append(iocs, &FoundIOC{IOC: library, Type: File, Metadata: map[string]string{"binary": "import"}})
+	}
+	return iocs, nil
+}
@@ -0,0 +1,158 @@
+package ioc
+
+import (
+	"bytes"
+	"crypto/md5"
+	"debug/pe"
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func utf16LE(s string) []byte {
+	ret := []byte{}
+	for _, c := range []byte(s) {
+		ret = append(ret, c, 0)
+	}
+	return ret
+}
+
+// buildPE Build a PE32 with one .idata section importing CreateProcessA from KERNEL32.dll and ordinal 115 from WS2_32.dll
+func buildPE(extra []byte) []byte {
+	const fileAlignment, sectionRVA = 0x200, 0x1000
+	section := make([]byte, 0x200)
+	put := func(offset int, value interface{}) {
+		buffer := new(bytes.Buffer)
+		binary.Write(buffer, binary.LittleEndian, value)
+		copy(section[offset:], buffer.Bytes())
+	}
+	// Import descriptors (lookup table, name), then the lookup tables, hint/names, and DLL names
+	put(0, []uint32{sectionRVA + 0x80, 0, 0, sectionRVA + 0x100, sectionRVA + 0x80})
+	put(20, []uint32{sectionRVA + 0x90, 0, 0, sectionRVA + 0x110, sectionRVA + 0x90})
+	put(0x80, []uint32{sectionRVA + 0xc0, 0})
+	put(0x90, []uint32{0x80000073, 0})
+	copy(section[0xc2:], "CreateProcessA")
+	copy(section[0x100:], "KERNEL32.dll")
+	copy(section[0x110:], "WS2_32.dll")
+
+	ret := new(bytes.Buffer)
+	dos := make([]byte, 0x40)
+	copy(dos, "MZ")
+	dos[0x3c] = 0x40
+	ret.Write(dos)
+	ret.WriteString("PE\x00\x00")
+	binary.Write(ret, binary.LittleEndian, pe.FileHeader{
+		Machine:              pe.IMAGE_FILE_MACHINE_I386,
+		NumberOfSections:     1,
+		TimeDateStamp:        1583230953,
+		SizeOfOptionalHeader: uint16(binary.Size(pe.OptionalHeader32{})),
+		Characteristics:      0x102,
+	})
+	optional := pe.OptionalHeader32{
+		Magic:               0x10b,
+		SectionAlignment:    0x1000,
+		FileAlignment:       fileAlignment,
+		SizeOfImage:         0x2000,
+		SizeOfHeaders:       fileAlignment,
+		NumberOfRvaAndSizes: 16,
+	}
+	optional.DataDirectory[1] = pe.DataDirectory{VirtualAddress: sectionRVA, Size: 40}
+	binary.Write(ret, binary.LittleEndian, optional)
+	header := pe.SectionHeader32{
+		VirtualSize:      uint32(len(section)),
+		VirtualAddress:   sectionRVA,
+		SizeOfRawData:    uint32(len(section)),
+		PointerToRawData: fileAlignment,
+		Characteristics:  0xc0000040,
+	}
+	copy(header.Name[:], ".idata")
+	binary.Write(ret, binary.LittleEndian, header)
+	ret.Write(make([]byte, fileAlignment-ret.Len()))
+	ret.Write(section)
+	ret.Write(extra)
+	return ret.Bytes()
+}
+
+func TestExtractStrings(t *testing.T) {
+	data := append([]byte("\x00\x01hello world\x00ab\x01"), utf16LE("wide string")...)
+	data = append(data, 0xff, 0xfe, 'x')
+	want := []string{"hello world", "wide string"}
+	if got := ExtractStrings(data, 4); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestGetIOCsFromBinary(t *testing.T) {
+	data := buildPE(append(utf16LE("http://c2.evil.com/gate.php"), []byte("\x00\x00\x00beacon 93.184.216.34\x00")...))
+	if got := DetectContentType(data); got != ContentPE {
+		t.Fatalf("Detected as %s", got)
+	}
+	got, err := GetIOCsFromBinary(data, ContentOptions{GetFangedIOCs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imphash := md5.Sum([]byte("kernel32.createprocessa,ws2_32.ord115"))
+	section := md5.Sum(data[0x200:0x400])
+	file := map[string]string{
+		"binary": "file", "format": "pe", "compile_time": "2020-03-03T10:22:33Z",
+		"imphash": hex.EncodeToString(imphash[:]), "section..idata.md5": hex.EncodeToString(section[:]),
+	}
+	want := []*FoundIOC{
+		{IOC: "KERNEL32.dll", Type: File, Metadata: map[string]string{"binary": "import"}},
+		{IOC: "WS2_32.dll", Type: File, Metadata: map[string]string{"binary": "import"}},
+	}
+	if len(got) < 3 || got[0].Type != MD5 || got[1].Type != SHA1 || got[2].Type != SHA256 || !reflect.DeepEqual(got[0].Metadata, file) {
+		t.Fatalf("The file hashes should be first, got %v", got)
+	}
+	if !reflect.DeepEqual(got[3:3+len(want)], want) {
+		t.Errorf("got %v, wanted %v", got[3:], want)
+		for _, ioc := range got {
+			t.Log(ioc.IOC, ioc.Type, ioc.Metadata)
+		}
+	}
+
+	// The strings
+	for _, ioc := range []*FoundIOC{{IOC: "http://c2.evil.com/gate.php", Type: URL}, {IOC: "93.184.216.34", Type: IPv4}} {
+		if !containsFoundIOC(got, ioc.ToIOC()) {
+			t.Errorf("Missing %s from the strings", ioc.IOC)
+		}
+	}
+}
+
+func TestGetIOCsFromBinaryNotExecutable(t *testing.T) {
+	data := append([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x00"), []byte("tEXtComment\x00http://c2.evil.com/gate.php\x00")...)
+	got, err := GetIOCsFromBinary(data, ContentOptions{GetFangedIOCs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ioc := range got {
+		if ioc.Type == MD5 || ioc.Type == SHA1 || ioc.Type == SHA256 {
+			t.Errorf("Only executables should be hashed, got %v", ioc)
+		}
+	}
+	if !containsFoundIOC(got, &IOC{"http://c2.evil.com/gate.php", URL}) {
+		t.Errorf("Missing the URL from the strings, got %v", got)
+	}
+}
+
+func TestGetIOCsFromBinaryELF(t *testing.T) {
+	// The test binary is an ELF file on Linux
+	data, err := ioutil.ReadFile(os.Args[0])
+	if err != nil || DetectContentType(data) != ContentELF {
+		t.Skip("The test binary is not an ELF file")
+	}
+	got, err := GetIOCsFromBinary(data, ContentOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Metadata["format"] != "elf" || got[0].Metadata["machine"] == "" {
+		t.Errorf("Missing ELF metadata, got %v", got[0].Metadata)
+	}
+	if got[0].Metadata["section..text.md5"] == "" {
+		t.Errorf("Missing the hash of the .text section")
+	}
+}
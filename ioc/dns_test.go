@@ -0,0 +1,87 @@
+package ioc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var blocklistTestIOCs = []*IOC{
+	{"Example[.]com", Domain},
+	{"example.com", Domain},
+	{"hxxps[://]bücher[.]de/path", URL},
+	{"http://1.2.3.4/path", URL},
+	{"good.example.org", Domain},
+	{"example.org", Domain},
+	{"1.2.3.4", IPv4},
+}
+
+func TestBlocklistDomains(t *testing.T) {
+	got := BlocklistDomains(blocklistTestIOCs, []string{"Good.Example.org."})
+	want := []string{"example.com", "xn--bcher-kva.de"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v wanted %v", got, want)
+	}
+
+	got = BlocklistDomains(blocklistTestIOCs, []string{"example.org", "bücher.de"})
+	want = []string{"example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v wanted %v", got, want)
+	}
+
+	// Blocking a parent would block the allowlisted subdomain too
+	got = BlocklistDomains([]*IOC{{IOC: "evil.com", Type: Domain}, {IOC: "www.evil.com", Type: Domain}, {IOC: "notevil.com", Type: Domain}}, []string{"safe.evil.com"})
+	want = []string{"www.evil.com", "notevil.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v wanted %v", got, want)
+	}
+}
+
+func TestPrintIOCsHostsAllowlist(t *testing.T) {
+	// Hosts files block exact names, so the parent of an allowlisted domain is still blocked
+	iocs := []*IOC{{IOC: "evil.com", Type: Domain}, {IOC: "safe.evil.com", Type: Domain}, {IOC: "www.safe.evil.com", Type: Domain}}
+	options := BlocklistOptions{Allowlist: []string{"safe.evil.com"}}
+	if got, want := PrintIOCsHosts(iocs, options), "0.0.0.0 evil.com"; got != want {
+		t.Errorf("hosts got:\n%s\nwanted:\n%s", got, want)
+	}
+	if got, want := PrintIOCsDnsmasq(iocs, options), ""; got != want {
+		t.Errorf("dnsmasq got:\n%s\nwanted:\n%s", got, want)
+	}
+}
+
+func TestDNSBlocklists(t *testing.T) {
+	iocs := []*IOC{{"example[.]com", Domain}, {"hxxp[://]bad[.]com/x", URL}}
+	options := BlocklistOptions{Serial: 1234}
+
+	tests := []struct {
+		name string
+		got  string
+		want []string
+	}{
+		{"hosts", PrintIOCsHosts(iocs, options), []string{"0.0.0.0 example.com", "0.0.0.0 bad.com"}},
+		{"rpz", PrintIOCsRPZ(iocs, options), []string{
+			"$TTL 300",
+			"$ORIGIN rpz.local.",
+			"@ IN SOA localhost. root.localhost. (1234 3600 600 86400 300)",
+			"@ IN NS localhost.",
+			"example.com CNAME .",
+			"*.example.com CNAME .",
+			"bad.com CNAME .",
+			"*.bad.com CNAME .",
+		}},
+		{"dnsmasq", PrintIOCsDnsmasq(iocs, options), []string{"address=/example.com/0.0.0.0", "address=/bad.com/0.0.0.0"}},
+		{"unbound", PrintIOCsUnbound(iocs, BlocklistOptions{Sinkhole: "::"}), []string{
+			`local-zone: "example.com." redirect`,
+			`local-data: "example.com. AAAA ::"`,
+			`local-zone: "bad.com." redirect`,
+			`local-data: "bad.com. AAAA ::"`,
+		}},
+		{"adblock", PrintIOCsAdblock(iocs, options), []string{"||example.com^", "||bad.com^"}},
+	}
+
+	for _, test := range tests {
+		if want := strings.Join(test.want, "\n"); test.got != want {
+			t.Errorf("%s got:\n%s\nwanted:\n%s", test.name, test.got, want)
+		}
+	}
+}
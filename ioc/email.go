@@ -0,0 +1,263 @@
+package ioc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxEmailDepth Deepest multiparts are followed
+const maxEmailDepth = 16
+
+// GetIOCsFromEmail Get the IOCs from an email message (RFC 5322/MIME).
+// The indicators in the headers (see GetIOCsFromEmailHeader) come first, then the IOCs in the bodies and attachments.
+// Links in HTML bodies are always included, even though they are fanged.
+// IOCs from attachments have the attachment's file name in their "attachment" Metadata.
+func GetIOCsFromEmail(reader io.Reader, getFangedIOCs bool) ([]*FoundIOC, error) {
+	msg, err := mail.ReadMessage(reader)
+	if err != nil {
+		return nil, err
+	}
+	return getIOCsFromEmail(msg, newContentExtractor(ContentOptions{GetFangedIOCs: getFangedIOCs}), 0)
+}
+
+// getIOCsFromEmail Get the IOCs from an email, depth is the layers of archives, compression, and attached emails it is in
+func getIOCsFromEmail(msg *mail.Message, e *contentExtractor, depth int) ([]*FoundIOC, error) {
+	if err := e.checkDepth(depth); err != nil {
+		return nil, err
+	}
+	iocs := GetIOCsFromEmailHeader(msg.Header)
+	for _, ioc := range GetIOCs(decodeEmailHeader(msg.Header.Get("Subject")), e.options.GetFangedIOCs) {
+		iocs = appendUniqueIOCs(iocs, &FoundIOC{IOC: ioc.IOC, Type: ioc.Type, Metadata: map[string]string{"header": "Subject"}})
+	}
+
+	body, err := getIOCsFromEmailPart(msg.Header, msg.Body, e, depth, 0)
+	return appendUniqueIOCs(iocs, body...), err
+}
+
+var (
+	// receivedHost The hosts in from and by clauses, and the reverse DNS name before the IP in a Received header
+	receivedHost = regexp.MustCompile(`(?i)(?:\b(?:from|by)\s+([^\s;()\[\]]+))|(?:\(([^\s;()\[\]]+)\s*\[)`)
+	// receivedIP IPs in brackets in a Received header
+	receivedIP = regexp.MustCompile(`\[(?:IPv6:)?([^\]]+)\]`)
+)
+
+// GetIOCsFromEmailHeader Get the indicators from the headers of an email: the sender, reply-to, and return-path addresses,
+// the IPs and hostnames of each Received hop, and the domain of the Message-ID.
+// Each has the header it came from in its "header" Metadata, and Received hops have their number (starting from 1 at the top) in "hop".
+func GetIOCsFromEmailHeader(header mail.Header) []*FoundIOC {
+	iocs := []*FoundIOC{}
+	add := func(value string, t Type, metadata map[string]string) {
+		iocs = appendUniqueIOCs(iocs, &FoundIOC{IOC: value, Type: t, Metadata: metadata})
+	}
+
+	for _, name := range []string{"From", "Sender", "Reply-To", "Return-Path"} {
+		for _, value := range header[name] {
+			for _, address := range emailAddresses(value) {
+				add(address, Email, map[string]string{"header": name})
+			}
+		}
+	}
+
+	for i, value := range header["Received"] {
+		metadata := map[string]string{"header": "Received", "hop": strconv.Itoa(i + 1)}
+		// The date is after the last ;
+		if end := strings.LastIndex(value, ";"); end != -1 {
+			value = value[:end]
+		}
+
+		for _, match := range receivedHost.FindAllStringSubmatch(value, -1) {
+			host := strings.TrimSuffix(match[1]+match[2], ".")
+			if net.ParseIP(host) == nil && ParseIOC(host).Type == Domain && ParseIOC(host).IOC == host {
+				add(host, Domain, metadata)
+			}
+		}
+		for _, match := range receivedIP.FindAllStringSubmatch(value, -1) {
+			if ip := net.ParseIP(match[1]); ip != nil {
+				add(match[1], ipType(ip), metadata)
+			}
+		}
+		for _, ip := range iocRegexes[IPv4].FindAllString(value, -1) {
+			if net.ParseIP(ip) != nil {
+				add(ip, IPv4, metadata)
+			}
+		}
+	}
+
+	for _, value := range header["X-Originating-Ip"] {
+		ip := strings.Trim(strings.TrimSpace(value), "[]")
+		if parsed := net.ParseIP(ip); parsed != nil {
+			add(ip, ipType(parsed), map[string]string{"header": "X-Originating-IP"})
+		}
+	}
+
+	for _, value := range header["Message-Id"] {
+		id := strings.Trim(strings.TrimSpace(value), "<>")
+		if at := strings.LastIndex(id, "@"); at != -1 {
+			if domain := id[at+1:]; ParseIOC(domain).Type == Domain {
+				add(domain, Domain, map[string]string{"header": "Message-ID"})
+			}
+		}
+	}
+
+	return iocs
+}
+
+// getIOCsFromEmailPart Get the IOCs from the body of a message or a part of a multipart message.
+// depth is the depth of the message (see getIOCsFromEmail), and parts is how many multiparts the part is in.
+func getIOCsFromEmailPart(header map[string][]string, body io.Reader, e *contentExtractor, depth, parts int) ([]*FoundIOC, error) {
+	if parts > maxEmailDepth {
+		return nil, fmt.Errorf("email parts nested too deeply")
+	}
+	get := func(name string) string {
+		if values := header[name]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	mediaType, params, err := mime.ParseMediaType(get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	switch strings.ToLower(strings.TrimSpace(get("Content-Transfer-Encoding"))) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	iocs := []*FoundIOC{}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return iocs, err
+			}
+			partIOCs, err := getIOCsFromEmailPart(part.Header, part, e, depth, parts+1)
+			iocs = appendUniqueIOCs(iocs, partIOCs...)
+			if err != nil {
+				return iocs, err
+			}
+		}
+		return iocs, nil
+	}
+
+	data, err := e.read(body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attachments
+	_, dispositionParams, _ := mime.ParseMediaType(get("Content-Disposition"))
+	filename := decodeEmailHeader(dispositionParams["filename"])
+	if filename == "" {
+		filename = decodeEmailHeader(params["name"])
+	}
+	if filename != "" || strings.HasPrefix(get("Content-Disposition"), "attachment") || !strings.HasPrefix(mediaType, "text/") {
+		if mediaType == "message/rfc822" {
+			msg, err := mail.ReadMessage(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			iocs, err = getIOCsFromEmail(msg, e, depth+1)
+			return iocs, err
+		}
+
+		attachmentIOCs, err := e.extract(bytes.NewReader(data), int64(len(data)), depth+1)
+		if filename != "" {
+			attachmentIOCs = append(FoundIOCs(GetIOCs(filename, e.options.GetFangedIOCs), ""), attachmentIOCs...)
+		}
+		for _, ioc := range attachmentIOCs {
+			setMetadata(ioc, "attachment", filename)
+		}
+		return appendUniqueIOCs(iocs, attachmentIOCs...), err
+	}
+
+	if mediaType == "text/html" {
+		text := string(data)
+		htmlIOCs, err := GetIOCsFromHTML(&text)
+		if err != nil {
+			return nil, err
+		}
+		iocs = FoundIOCs(htmlIOCs, "")
+
+		// Links are where phishing emails hide their URLs
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		links := []string{}
+		doc.Find("[href], [src]").Each(func(i int, sel *goquery.Selection) {
+			for _, attr := range []string{"href", "src"} {
+				if link, ok := sel.Attr(attr); ok {
+					links = append(links, link)
+				}
+			}
+		})
+		return e.reveal(appendUniqueIOCs(iocs, FoundIOCs(GetIOCs(strings.Join(links, "\n"), true), "")...), text), nil
+	}
+
+	return GetIOCsFromText(string(data), e.options), nil
+}
+
+// emailAddresses Get the addresses in an address list header
+func emailAddresses(value string) []string {
+	addresses := []string{}
+	if list, err := mail.ParseAddressList(value); err == nil {
+		for _, address := range list {
+			addresses = append(addresses, address.Address)
+		}
+		return addresses
+	}
+
+	// Fall back to anything that looks like an address, ex: for an invalid From with a display name
+	return append(addresses, iocRegexes[Email].FindAllString(strings.NewReplacer("<", " ", ">", " ").Replace(value), -1)...)
+}
+
+// decodeEmailHeader Decode encoded words (RFC 2047) in a header value
+func decodeEmailHeader(value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+func ipType(ip net.IP) Type {
+	if ip.To4() != nil {
+		return IPv4
+	}
+	return IPv6
+}
+
+// appendUniqueIOCs Append the IOCs that are not already in iocs
+func appendUniqueIOCs(iocs []*FoundIOC, add ...*FoundIOC) []*FoundIOC {
+outer:
+	for _, ioc := range add {
+		for _, existing := range iocs {
+			if reflect.DeepEqual(existing, ioc) {
+				continue outer
+			}
+		}
+		iocs = append(iocs, ioc)
+	}
+	return iocs
+}
@@ -0,0 +1,134 @@
+package ioc
+
+import (
+	"encoding/base64"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var testEmail = strings.ReplaceAll(`Received: from mail.evil.com (mail.evil.com [203.0.113.5])
+	by mx.example.org (Postfix) with ESMTPS id 1234;
+	Tue, 3 Mar 2020 10:22:33 +0000
+Received: from [10.0.0.7] (unknown [IPv6:2001:db8::1])
+	by mail.evil.com with ESMTPSA; Tue, 3 Mar 2020 10:22:30 +0000
+Return-Path: <bounce@evil.com>
+From: "IT Support" <support@evil.com>
+Reply-To: =?UTF-8?Q?Help_Desk?= <helpdesk@collect.net>
+To: victim@example.org
+Subject: Reset your password at portal[.]evil[.]com
+Message-ID: <abc123@mail.evil.com>
+X-Originating-IP: [198.51.100.7]
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="outer"
+
+--outer
+Content-Type: multipart/alternative; boundary="inner"
+
+--inner
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Go to hxxp://login[.]evil[.]com/reset?user=3Dvictim to reset your pass=
+word.
+
+--inner
+Content-Type: text/html; charset=utf-8
+
+<html><body><p>Go to <a href="http://login.evil.com/reset?user=victim">our portal</a> to reset your password.</p></body></html>
+--inner--
+
+--outer
+Content-Type: application/octet-stream; name="invoice.pdf.exe"
+Content-Disposition: attachment; filename="invoice.pdf.exe"
+Content-Transfer-Encoding: base64
+
+`+base64.StdEncoding.EncodeToString([]byte("payload calls back to 192[.]0[.]2[.]1"))+`
+--outer--
+`, "\n", "\r\n")
+
+func TestGetIOCsFromEmailHeader(t *testing.T) {
+	msg, err := mail.ReadMessage(strings.NewReader(testEmail))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hop := func(n string) map[string]string {
+		return map[string]string{"header": "Received", "hop": n}
+	}
+	want := []*FoundIOC{
+		{IOC: "support@evil.com", Type: Email, Metadata: map[string]string{"header": "From"}},
+		{IOC: "helpdesk@collect.net", Type: Email, Metadata: map[string]string{"header": "Reply-To"}},
+		{IOC: "bounce@evil.com", Type: Email, Metadata: map[string]string{"header": "Return-Path"}},
+		{IOC: "mail.evil.com", Type: Domain, Metadata: hop("1")},
+		{IOC: "mx.example.org", Type: Domain, Metadata: hop("1")},
+		{IOC: "203.0.113.5", Type: IPv4, Metadata: hop("1")},
+		{IOC: "mail.evil.com", Type: Domain, Metadata: hop("2")},
+		{IOC: "10.0.0.7", Type: IPv4, Metadata: hop("2")},
+		{IOC: "2001:db8::1", Type: IPv6, Metadata: hop("2")},
+		{IOC: "198.51.100.7", Type: IPv4, Metadata: map[string]string{"header": "X-Originating-IP"}},
+		{IOC: "mail.evil.com", Type: Domain, Metadata: map[string]string{"header": "Message-ID"}},
+	}
+	if got := GetIOCsFromEmailHeader(msg.Header); !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%v\nwanted:\n%v", got, want)
+		for _, ioc := range got {
+			t.Log(ioc.IOC, ioc.Type, ioc.Metadata)
+		}
+	}
+}
+
+func TestGetIOCsFromEmail(t *testing.T) {
+	if got := DetectContentType([]byte(testEmail)); got != ContentEmail {
+		t.Errorf("Email detected as %s", got)
+	}
+	if got := DetectContentType([]byte("Note: this is not an email\nbut it mentions From: me")); got == ContentEmail {
+		t.Errorf("Text detected as an email")
+	}
+
+	got, err := GetIOCsFromEmail(strings.NewReader(testEmail), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only check the IOCs from the subject and body, the headers are checked above
+	body := []*FoundIOC{}
+	for _, ioc := range got {
+		if ioc.Metadata["header"] == "" || ioc.Metadata["header"] == "Subject" {
+			body = append(body, ioc)
+		}
+	}
+	sort.SliceStable(body, func(i, j int) bool { return body[i].Type < body[j].Type })
+	attachment := map[string]string{"attachment": "invoice.pdf.exe"}
+	want := []*FoundIOC{
+		{IOC: "portal[.]evil[.]com", Type: Domain, Metadata: map[string]string{"header": "Subject"}},
+		{IOC: "login[.]evil[.]com", Type: Domain},
+		{IOC: "login.evil.com", Type: Domain},
+		{IOC: "192[.]0[.]2[.]1", Type: IPv4, Metadata: attachment},
+		{IOC: "hxxp://login[.]evil[.]com/reset?user=victim", Type: URL},
+		{IOC: "http://login.evil.com/reset?user=victim", Type: URL},
+		{IOC: "invoice.pdf.exe", Type: File, Metadata: attachment},
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("got:\n%v\nwanted:\n%v", body, want)
+		for _, ioc := range body {
+			t.Log(ioc.IOC, ioc.Type, ioc.Metadata)
+		}
+	}
+
+	if _, err := GetIOCsFromEmail(strings.NewReader("not an email"), false); err == nil {
+		t.Errorf("Should have errored on something that is not an email")
+	}
+
+	// Attachments are a layer deeper than the email
+	email := "From: a@example.com\r\nTo: b@example.com\r\nSubject: logs\r\nContent-Type: application/gzip; name=\"logs.gz\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n\r\n" + base64.StdEncoding.EncodeToString(gzipBytes([]byte("c2 at 1[.]2[.]3[.]4")))
+	if _, err := GetIOCsFromContentWithOptions([]byte(email), ContentOptions{MaxDepth: 1}); err == nil {
+		t.Errorf("Should have errored on an attachment over the depth limit")
+	}
+	iocs, err := GetIOCsFromContentWithOptions([]byte(email), ContentOptions{MaxDepth: 2})
+	if err != nil || !containsFoundIOC(iocs, &IOC{IOC: "1[.]2[.]3[.]4", Type: IPv4}) {
+		t.Errorf("got %v %v, wanted the IOC in the attachment", iocs, err)
+	}
+}
@@ -1,6 +1,7 @@
 package ioc
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -47,7 +48,7 @@ func GetIOCsFromRSS(ctx context.Context, url string) ([]*IOC, error) {
 	return iocs, nil
 }
 
-// GetIOCsFromURLPage Given a url get IOCs from the _text_ of the page
+// GetIOCsFromURLPage Given a url get IOCs from the _text_ of the page, or of the PDF if it is a PDF
 func GetIOCsFromURLPage(req *http.Request) ([]*IOC, error) {
 	if req == nil {
 		return nil, fmt.Errorf("no request")
@@ -64,7 +65,13 @@ func GetIOCsFromURLPage(req *http.Request) ([]*IOC, error) {
 	}
 	bodyString := string(body)
 
-	return GetIOCsFromHTML(&bodyString)
+	var iocs []*IOC
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/pdf") || DetectContentType(body) == ContentPDF {
+		iocs, err = GetIOCsFromPDF(bytes.NewReader(body), int64(len(body)))
+	} else {
+		iocs, err = GetIOCsFromHTML(&bodyString)
+	}
+	return iocs, err
 }
 
 // GetIOCsFromHTML Takes a html page as a string and will extract the IOCs
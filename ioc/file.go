@@ -0,0 +1,354 @@
+package ioc
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"debug/elf"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/mail"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ulikunitz/xz"
+)
+
+// Content types returned by DetectContentType
+const (
+	ContentText   = "text"
+	ContentHTML   = "html"
+	ContentPDF    = "pdf"
+	ContentOffice = "office" // DOCX, XLSX, PPTX
+	ContentEmail  = "email"  // RFC 5322/MIME messages
+	ContentZip    = "zip"
+	ContentTar    = "tar"
+	ContentGzip   = "gzip"
+	ContentBzip2  = "bzip2"
+	ContentXz     = "xz"
+	ContentPcap   = "pcap" // pcap and pcapng packet captures
+	ContentPE     = "pe"   // Windows executables
+	ContentELF    = "elf"  // Linux executables
+	ContentBinary = "binary"
+)
+
+const (
+	// defaultMaxDepth Most layers of nested archives, compression, and emails (ex: .tar.gz in a .zip) opened by default
+	defaultMaxDepth = 4
+	// defaultMaxSize Most bytes decompressed in total by default
+	defaultMaxSize = 256 << 20
+	// contentHeaderSize Bytes at the start of content used to detect its type
+	contentHeaderSize = 64 << 10
+)
+
+// DefaultPasswords Passwords tried on encrypted zips by default, the common password of malware samples
+var DefaultPasswords = []string{"infected"}
+
+// ContentOptions Options used when getting IOCs from content, with limits to protect against zip bombs
+type ContentOptions struct {
+	GetFangedIOCs bool
+	// MaxDepth Most layers of nested archives, compression, and emails (attached, or in archives) opened, defaults to 4
+	MaxDepth int
+	// MaxSize Most bytes decompressed in total from all archives, compression, and PDF streams, and read from a file, defaults to 256MB
+	MaxSize int64
+	// Passwords Passwords tried on encrypted zips, defaults to DefaultPasswords
+	Passwords []string
+	// MinStringLength Shortest string extracted from binaries, defaults to 4
+	MinStringLength int
+	// Decode Also search base64, hex, and URL encoded text in text, HTML, and PDFs, see GetIOCsDecoded
+	Decode bool
+	// Deobfuscate Also search text, HTML, and PDFs after resolving JavaScript and PowerShell string tricks, see GetIOCsDeobfuscated.
+	// This is done before decoding, so encoded strings that were split up are decoded.
+	Deobfuscate bool
+}
+
+// DetectContentType Get the type of content from its first bytes, one of the Content* constants
+func DetectContentType(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		return ContentGzip
+	case bytes.HasPrefix(data, []byte("BZh")) && len(data) > 3 && data[3] >= '1' && data[3] <= '9':
+		return ContentBzip2
+	case bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\n\r "), []byte("%PDF-")):
+		return ContentPDF
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")) && bytes.Contains(data[:minInt(len(data), 4096)], []byte("[Content_Types].xml")):
+		// Office documents are zips, that usually start with the content types part
+		return ContentOffice
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")) || bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return ContentZip
+	case bytes.HasPrefix(data, []byte("\xfd7zXZ\x00")):
+		return ContentXz
+	case len(data) > 262 && bytes.HasPrefix(data[257:], []byte("ustar")):
+		return ContentTar
+	case isPcap(data):
+		return ContentPcap
+	case isPE(bytes.NewReader(data), int64(len(data))):
+		return ContentPE
+	case bytes.HasPrefix(data, []byte(elf.ELFMAG)):
+		return ContentELF
+	}
+
+	if isEmail(data) {
+		return ContentEmail
+	}
+
+	mime := http.DetectContentType(data)
+	switch {
+	case strings.HasPrefix(mime, "text/html"):
+		return ContentHTML
+	case strings.HasPrefix(mime, "text/"):
+		return ContentText
+	}
+	return ContentBinary
+}
+
+// GetIOCsFromContent Detect the type of the data and get the IOCs from it, with the default ContentOptions.
+// Compressed data is decompressed and archives (zip, tar) are opened, each IOC from an archive has the path of the member it was in
+// as its "member" Metadata.  HTML, PDFs, and Office documents have IOCs taken from their text,
+// emails and packet captures are parsed with GetIOCsFromEmail and GetIOCsFromPcap, and everything else is searched as text.
+func GetIOCsFromContent(data []byte, getFangedIOCs bool) ([]*FoundIOC, error) {
+	return GetIOCsFromContentWithOptions(data, ContentOptions{GetFangedIOCs: getFangedIOCs})
+}
+
+// GetIOCsFromContentWithOptions Detect the type of the data and get the IOCs from it, see GetIOCsFromContent.
+// If an archive can not be fully read, the IOCs found are returned with the error.
+func GetIOCsFromContentWithOptions(data []byte, options ContentOptions) ([]*FoundIOC, error) {
+	return newContentExtractor(options).extract(bytes.NewReader(data), int64(len(data)), 0)
+}
+
+// GetIOCsFromText Get the IOCs from text, see GetIOCs, also searching the text after deobfuscating and decoding it if the options are on
+func GetIOCsFromText(data string, options ContentOptions) []*FoundIOC {
+	return newContentExtractor(options).reveal(FoundIOCs(GetIOCs(data, options.GetFangedIOCs), ""), data)
+}
+
+// contentExtractor Gets IOCs from content, keeping track of how much has been decompressed
+type contentExtractor struct {
+	options      ContentOptions
+	decompressed int64
+}
+
+func newContentExtractor(options ContentOptions) *contentExtractor {
+	if options.MaxDepth == 0 {
+		options.MaxDepth = defaultMaxDepth
+	}
+	if options.MaxSize == 0 {
+		options.MaxSize = defaultMaxSize
+	}
+	if options.Passwords == nil {
+		options.Passwords = DefaultPasswords
+	}
+	return &contentExtractor{options: options}
+}
+
+// read Read all of a decompressing reader, erroring if it goes over the total size limit
+func (e *contentExtractor) read(reader io.Reader) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(reader, e.options.MaxSize-e.decompressed+1))
+	e.decompressed += int64(len(data))
+	if e.decompressed > e.options.MaxSize {
+		return nil, fmt.Errorf("decompressed more than %d bytes", e.options.MaxSize)
+	}
+	return data, err
+}
+
+// checkDepth Check that an archive, compression, or email at the depth can be opened, the depth is shared by all of them
+func (e *contentExtractor) checkDepth(depth int) error {
+	if depth >= e.options.MaxDepth {
+		return fmt.Errorf("more than %d layers of archives, compression, and emails", e.options.MaxDepth)
+	}
+	return nil
+}
+
+// reveal Add the IOCs hidden in the data by obfuscation and encoding to the IOCs, if the options are on
+func (e *contentExtractor) reveal(iocs []*FoundIOC, data string) []*FoundIOC {
+	if e.options.Deobfuscate {
+		deobfuscated := Deobfuscate(data)
+		iocs = appendDeobfuscatedIOCs(iocs, data, deobfuscated)
+		data = deobfuscated
+	}
+	if e.options.Decode {
+		iocs = appendDecodedIOCs(iocs, data)
+	}
+	return iocs
+}
+
+// extract Get the IOCs from the content, reading it from the reader as it is needed.
+// Only text and HTML are read in to memory, since they are searched all at once.
+func (e *contentExtractor) extract(reader io.ReaderAt, size int64, depth int) ([]*FoundIOC, error) {
+	header, err := readHeader(reader, size)
+	if err != nil {
+		return nil, err
+	}
+	content := io.NewSectionReader(reader, 0, size)
+
+	var decompressor io.Reader
+	contentType := DetectContentType(header)
+	switch contentType {
+	case ContentHTML:
+		data, err := ioutil.ReadAll(content)
+		if err != nil {
+			return nil, err
+		}
+		html := string(data)
+		iocs, err := GetIOCsFromHTML(&html)
+		return e.reveal(FoundIOCs(iocs, ""), html), err
+	case ContentPDF:
+		return getIOCsFromPDF(reader, size, e)
+	case ContentOffice:
+		iocs, err := getIOCsFromOffice(reader, size, e)
+		return FoundIOCs(iocs, ""), err
+	case ContentEmail:
+		msg, err := mail.ReadMessage(content)
+		if err != nil {
+			return nil, err
+		}
+		return getIOCsFromEmail(msg, e, depth)
+	case ContentPcap:
+		return GetIOCsFromPcap(content)
+	case ContentPE, ContentELF, ContentBinary:
+		return getIOCsFromBinary(reader, size, e.options)
+	case ContentGzip, ContentBzip2, ContentXz, ContentZip, ContentTar:
+		if err := e.checkDepth(depth); err != nil {
+			return nil, err
+		}
+	default:
+		data, err := ioutil.ReadAll(content)
+		if err != nil {
+			return nil, err
+		}
+		return GetIOCsFromText(string(data), e.options), nil
+	}
+
+	switch contentType {
+	case ContentZip:
+		return e.extractZip(reader, size, depth)
+	case ContentTar:
+		return e.extractTar(content, depth)
+	case ContentGzip:
+		reader, err := gzip.NewReader(content)
+		if err != nil {
+			return nil, err
+		}
+		decompressor = reader
+	case ContentBzip2:
+		decompressor = bzip2.NewReader(content)
+	case ContentXz:
+		reader, err := xz.NewReader(content)
+		if err != nil {
+			return nil, err
+		}
+		decompressor = reader
+	}
+
+	decompressed, err := e.read(decompressor)
+	if err != nil {
+		return nil, err
+	}
+	return e.extract(bytes.NewReader(decompressed), int64(len(decompressed)), depth+1)
+}
+
+// readHeader Read the start of the content, to detect its type
+func readHeader(reader io.ReaderAt, size int64) ([]byte, error) {
+	header := make([]byte, minInt(int(size), contentHeaderSize))
+	if n, err := reader.ReadAt(header, 0); err != nil && !(err == io.EOF && n == len(header)) {
+		return nil, err
+	}
+	return header, nil
+}
+
+// GetIOCsFromFile Get the IOCs from a file, see GetIOCsFromContent.  Each IOC has the path as its Source.
+func GetIOCsFromFile(path string, getFangedIOCs bool) ([]*FoundIOC, error) {
+	return GetIOCsFromFileWithOptions(path, ContentOptions{GetFangedIOCs: getFangedIOCs})
+}
+
+// GetIOCsFromFileWithOptions Get the IOCs from a file, see GetIOCsFromContentWithOptions.  Each IOC has the path as its Source.
+// The file is read as it is extracted, rather than all at once.  Files larger than MaxSize are not read.
+func GetIOCsFromFileWithOptions(path string, options ContentOptions) ([]*FoundIOC, error) {
+	e := newContentExtractor(options)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader, size, err := fileReader(path, file, e.options.MaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	iocs, err := e.extract(reader, size, 0)
+	for _, ioc := range iocs {
+		ioc.Source = path
+	}
+	if err != nil {
+		return iocs, fmt.Errorf("%s: %s", path, err)
+	}
+	return iocs, nil
+}
+
+// fileReader Get a reader of the file and its size, checking its size first so large files are not read.
+// Regular files are read from as they are extracted, and files that do not have a size (ex: pipes) are read in to memory,
+// at most maxSize bytes.
+func fileReader(path string, file *os.File, maxSize int64) (io.ReaderAt, int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	if info.IsDir() {
+		return nil, 0, fmt.Errorf("%s: is a directory", path)
+	}
+	if info.Size() > maxSize {
+		return nil, 0, fmt.Errorf("%s: larger than %d bytes", path, maxSize)
+	}
+	if info.Mode().IsRegular() {
+		return file, info.Size(), nil
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, 0, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, 0, fmt.Errorf("%s: larger than %d bytes", path, maxSize)
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+var (
+	emailHeaderLine = regexp.MustCompile(`^([!-9;-~]+:|[ \t])`)
+	// emailHeaderNames Headers from RFC 5322 (and MIME), an email must have minEmailHeaders of them
+	emailHeaderNames = map[string]bool{
+		"from": true, "sender": true, "reply-to": true, "to": true, "cc": true, "subject": true, "date": true,
+		"message-id": true, "in-reply-to": true, "references": true, "received": true, "return-path": true,
+		"mime-version": true, "content-type": true,
+	}
+)
+
+// minEmailHeaders Fewest different RFC 5322 headers an email must start with
+const minEmailHeaders = 3
+
+// isEmail Check if the data starts with the headers of an email, at least minEmailHeaders of the RFC 5322 ones before the first blank line
+func isEmail(data []byte) bool {
+	data = data[:minInt(len(data), 64<<10)]
+	// mbox files start with a From line
+	if bytes.HasPrefix(data, []byte("From ")) {
+		if end := bytes.IndexByte(data, '\n'); end != -1 {
+			data = data[end+1:]
+		}
+	}
+
+	headers := map[string]bool{}
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if line == "" {
+			break
+		}
+		if !emailHeaderLine.MatchString(line) {
+			return false
+		}
+		if name := strings.ToLower(strings.SplitN(line, ":", 2)[0]); emailHeaderNames[name] {
+			headers[name] = true
+		}
+	}
+	return len(headers) >= minEmailHeaders
+}
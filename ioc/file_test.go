@@ -0,0 +1,105 @@
+package ioc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func gzipBytes(data []byte) []byte {
+	ret := new(bytes.Buffer)
+	w := gzip.NewWriter(ret)
+	w.Write(data)
+	w.Close()
+	return ret.Bytes()
+}
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		input []byte
+		want  string
+	}{
+		{[]byte("just some text example[.]com"), ContentText},
+		{[]byte("<!DOCTYPE html><html><body>hi</body></html>"), ContentHTML},
+		{gzipBytes([]byte("text")), ContentGzip},
+		{[]byte("BZh91AY&SY"), ContentBzip2},
+		{[]byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"), ContentPDF},
+		{[]byte{0x00, 0x01, 0x02, 0xff}, ContentBinary},
+		{[]byte("From: a@example.com\r\nTo: b@example.com\r\nSubject: hi\r\n\r\nbody"), ContentEmail},
+		{[]byte("From a@example.com Thu Jan  1 00:00:00 2020\nReceived: from x\nMessage-ID: <1@x>\nDate: today\n\nbody"), ContentEmail},
+		// Text that only looks like a few headers is not an email
+		{[]byte("From: me\nNote: c2 is evil[.]com\n\nmore notes"), ContentText},
+		{[]byte("Received: yes\nStatus: done\n"), ContentText},
+	}
+	for i, test := range tests {
+		if got := DetectContentType(test.input); got != test.want {
+			t.Errorf("Test %d: got %s, wanted %s", i, got, test.want)
+		}
+	}
+}
+
+func TestGetIOCsFromContent(t *testing.T) {
+	tests := []struct {
+		input []byte
+		want  []*FoundIOC
+	}{
+		{[]byte("visit example[.]com"), []*FoundIOC{{IOC: "example[.]com", Type: Domain}}},
+		{[]byte("<html><body><p>visit example[.]com</p></body></html>"), []*FoundIOC{{IOC: "example[.]com", Type: Domain}}},
+		{gzipBytes(gzipBytes([]byte("visit example[.]com"))), []*FoundIOC{{IOC: "example[.]com", Type: Domain}}},
+		{buildPDF([]string{"<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [3 0 R] /Count 1 >>", "<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>", pdfStreamObject("", []byte("BT /F1 10 Tf (visit example[.]com) Tj ET"), false)}), []*FoundIOC{{IOC: "example[.]com", Type: Domain}}},
+	}
+	for i, test := range tests {
+		got, err := GetIOCsFromContent(test.input, false)
+		if err != nil {
+			t.Errorf("Test %d: %s", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Test %d: got %v, wanted %v", i, got, test.want)
+		}
+	}
+
+	// Too many layers of compression
+	data := []byte("visit example[.]com")
+	for i := 0; i <= defaultMaxDepth; i++ {
+		data = gzipBytes(data)
+	}
+	if _, err := GetIOCsFromContent(data, false); err == nil {
+		t.Errorf("Should have errored on too many layers of compression")
+	}
+}
+
+func TestGetIOCsFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-ioc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "report.txt.gz")
+	if err := ioutil.WriteFile(path, gzipBytes([]byte("c2 at 1[.]2[.]3[.]4")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetIOCsFromFile(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*FoundIOC{{IOC: "1[.]2[.]3[.]4", Type: IPv4, Source: path}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+
+	if _, err := GetIOCsFromFile(filepath.Join(dir, "missing"), false); err == nil {
+		t.Errorf("Should have errored on a missing file")
+	}
+	if _, err := GetIOCsFromFileWithOptions(path, ContentOptions{MaxSize: 10}); err == nil {
+		t.Errorf("Should have errored on a file over the size limit")
+	}
+	if _, err := GetIOCsFromFile(dir, false); err == nil {
+		t.Errorf("Should have errored on a directory")
+	}
+}
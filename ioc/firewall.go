@@ -0,0 +1,239 @@
+package ioc
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+)
+
+// FirewallOptions Options used when generating firewall blocklists
+type FirewallOptions struct {
+	Name string // Name of the set, table, or ACL.  Defaults to go-ioc
+}
+
+const defaultFirewallName = "go-ioc"
+
+// reservedNetworks Special purpose ranges that should never end up in a blocklist
+var reservedNetworks = parseCIDRs(
+	// IPv4
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+	"192.0.0.0/24", "192.0.2.0/24", "192.88.99.0/24", "192.168.0.0/16", "198.18.0.0/15",
+	"198.51.100.0/24", "203.0.113.0/24", "224.0.0.0/4", "240.0.0.0/4",
+	// IPv6
+	"::/128", "::1/128", "64:ff9b::/96", "100::/64", "2001::/23", "2001:db8::/32",
+	"fc00::/7", "fe80::/10", "ff00::/8",
+)
+
+// PrintIOCsCIDR Takes []IOC and returns the IPs aggregated in to a list of CIDRs
+func PrintIOCsCIDR(iocs []*IOC) string {
+	ret := []string{}
+	for _, network := range AggregateIPs(iocs) {
+		ret = append(ret, network.String())
+	}
+	return strings.Join(ret, "\n")
+}
+
+// PrintIOCsIptables Takes []IOC and returns ipset restore commands creating sets of the IPs for use in iptables rules.
+// IPv6 addresses go in a separate set with a 6 suffix.
+func PrintIOCsIptables(iocs []*IOC, options FirewallOptions) string {
+	options = firewallDefaults(options)
+	v4, v6 := splitIPFamilies(AggregateIPs(iocs))
+
+	ret := []string{}
+	for _, set := range []struct {
+		name     string
+		family   string
+		networks []*net.IPNet
+	}{{options.Name, "inet", v4}, {options.Name + "6", "inet6", v6}} {
+		if len(set.networks) == 0 {
+			continue
+		}
+		ret = append(ret, fmt.Sprintf("create %s hash:net family %s -exist", set.name, set.family))
+		for _, network := range set.networks {
+			ret = append(ret, fmt.Sprintf("add %s %s -exist", set.name, network))
+		}
+	}
+	return strings.Join(ret, "\n")
+}
+
+// PrintIOCsNftables Takes []IOC and returns an nftables table with an ipv4 and ipv6 set of the IPs
+func PrintIOCsNftables(iocs []*IOC, options FirewallOptions) string {
+	options = firewallDefaults(options)
+	v4, v6 := splitIPFamilies(AggregateIPs(iocs))
+
+	ret := fmt.Sprintf("table inet %s {\n", options.Name)
+	for _, set := range []struct {
+		name     string
+		family   string
+		networks []*net.IPNet
+	}{{"blocklist_v4", "ipv4_addr", v4}, {"blocklist_v6", "ipv6_addr", v6}} {
+		ret += fmt.Sprintf("\tset %s {\n\t\ttype %s\n\t\tflags interval\n", set.name, set.family)
+		if len(set.networks) > 0 {
+			ret += fmt.Sprintf("\t\telements = { %s }\n", joinNetworks(set.networks, ", "))
+		}
+		ret += "\t}\n"
+	}
+	return ret + "}"
+}
+
+// PrintIOCsPf Takes []IOC and returns a pf table of the IPs
+func PrintIOCsPf(iocs []*IOC, options FirewallOptions) string {
+	options = firewallDefaults(options)
+	networks := AggregateIPs(iocs)
+	if len(networks) == 0 {
+		return fmt.Sprintf("table <%s> persist", options.Name)
+	}
+	return fmt.Sprintf("table <%s> persist { \\\n\t%s \\\n}", options.Name, joinNetworks(networks, ", \\\n\t"))
+}
+
+// PrintIOCsCiscoACL Takes []IOC and returns Cisco IOS extended ACLs denying traffic to and from the IPs.
+// Each ACL ends with a permit so only the IOCs are blocked.
+func PrintIOCsCiscoACL(iocs []*IOC, options FirewallOptions) string {
+	options = firewallDefaults(options)
+	v4, v6 := splitIPFamilies(AggregateIPs(iocs))
+
+	ret := []string{}
+	if len(v4) > 0 {
+		ret = append(ret, "ip access-list extended "+options.Name)
+		for _, network := range v4 {
+			var address string
+			if ones, _ := network.Mask.Size(); ones == 32 {
+				address = "host " + network.IP.String()
+			} else {
+				// Cisco uses wildcard (inverted) masks
+				wildcard := make(net.IP, 4)
+				for i := range wildcard {
+					wildcard[i] = ^network.Mask[i]
+				}
+				address = network.IP.String() + " " + wildcard.String()
+			}
+			ret = append(ret, " deny ip any "+address, " deny ip "+address+" any")
+		}
+		ret = append(ret, " permit ip any any")
+	}
+	if len(v6) > 0 {
+		ret = append(ret, "ipv6 access-list "+options.Name+"6")
+		for _, network := range v6 {
+			ret = append(ret, " deny ipv6 any "+network.String(), " deny ipv6 "+network.String()+" any")
+		}
+		ret = append(ret, " permit ipv6 any any")
+	}
+	return strings.Join(ret, "\n")
+}
+
+// AggregateIPs Get the IPv4 and IPv6 IOCs as the smallest set of networks covering them.
+// Adjacent addresses are combined in to larger prefixes, and reserved ranges (private, loopback, documentation, etc) are excluded.
+// IPv4 networks are returned first, each family sorted by address.
+func AggregateIPs(iocs []*IOC) []*net.IPNet {
+	var v4, v6 []*big.Int
+	seen := map[string]bool{}
+	for _, ioc := range iocs {
+		if ioc.Type != IPv4 && ioc.Type != IPv6 {
+			continue
+		}
+		ip := net.ParseIP(ioc.Fang().IOC)
+		if ip == nil || isReservedIP(ip) || seen[ip.String()] {
+			continue
+		}
+		seen[ip.String()] = true
+
+		if ip4 := ip.To4(); ip4 != nil {
+			v4 = append(v4, new(big.Int).SetBytes(ip4))
+		} else {
+			v6 = append(v6, new(big.Int).SetBytes(ip.To16()))
+		}
+	}
+
+	return append(aggregateAddresses(v4, 32), aggregateAddresses(v6, 128)...)
+}
+
+// aggregateAddresses Combine the addresses of one family in to the minimal list of networks
+func aggregateAddresses(addresses []*big.Int, bits int) []*net.IPNet {
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Cmp(addresses[j]) < 0
+	})
+
+	networks := []*net.IPNet{}
+	one := big.NewInt(1)
+	for i := 0; i < len(addresses); {
+		// Find the run of consecutive addresses
+		start := new(big.Int).Set(addresses[i])
+		end := new(big.Int).Set(addresses[i])
+		i++
+		for i < len(addresses) && new(big.Int).Add(end, one).Cmp(addresses[i]) == 0 {
+			end.Set(addresses[i])
+			i++
+		}
+
+		// Split the range in to the largest aligned prefixes
+		for start.Cmp(end) <= 0 {
+			size := 0
+			for size < bits && start.Bit(size) == 0 {
+				size++
+			}
+			for size > 0 && new(big.Int).Add(start, new(big.Int).Sub(new(big.Int).Lsh(one, uint(size)), one)).Cmp(end) > 0 {
+				size--
+			}
+
+			ip := make(net.IP, bits/8)
+			b := start.Bytes()
+			copy(ip[len(ip)-len(b):], b)
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits-size, bits)})
+
+			start.Add(start, new(big.Int).Lsh(one, uint(size)))
+		}
+	}
+	return networks
+}
+
+// isReservedIP Check if an IP is in any of the reserved networks
+func isReservedIP(ip net.IP) bool {
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitIPFamilies Split networks in to IPv4 and IPv6
+func splitIPFamilies(networks []*net.IPNet) (v4 []*net.IPNet, v6 []*net.IPNet) {
+	for _, network := range networks {
+		if network.IP.To4() != nil {
+			v4 = append(v4, network)
+		} else {
+			v6 = append(v6, network)
+		}
+	}
+	return v4, v6
+}
+
+func joinNetworks(networks []*net.IPNet, sep string) string {
+	ret := []string{}
+	for _, network := range networks {
+		ret = append(ret, network.String())
+	}
+	return strings.Join(ret, sep)
+}
+
+func firewallDefaults(options FirewallOptions) FirewallOptions {
+	if options.Name == "" {
+		options.Name = defaultFirewallName
+	}
+	return options
+}
+
+// parseCIDRs Parse a list of known good CIDRs
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
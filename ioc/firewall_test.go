@@ -0,0 +1,102 @@
+package ioc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAggregateIPs(t *testing.T) {
+	tests := []struct {
+		input []*IOC
+		want  []string
+	}{
+		{
+			[]*IOC{{"8.8.8.8", IPv4}, {"8[.]8[.]8[.]8", IPv4}},
+			[]string{"8.8.8.8/32"},
+		},
+		{
+			// Two adjacent addresses aligned on a /31
+			[]*IOC{{"8.8.8.9", IPv4}, {"8.8.8.8", IPv4}},
+			[]string{"8.8.8.8/31"},
+		},
+		{
+			// 1.2.3.1 - 1.2.3.6 is not aligned
+			[]*IOC{{"1.2.3.1", IPv4}, {"1.2.3.2", IPv4}, {"1.2.3.3", IPv4}, {"1.2.3.4", IPv4}, {"1.2.3.5", IPv4}, {"1.2.3.6", IPv4}},
+			[]string{"1.2.3.1/32", "1.2.3.2/31", "1.2.3.4/31", "1.2.3.6/32"},
+		},
+		{
+			// Reserved ranges are excluded, and IPv6 comes after IPv4
+			[]*IOC{{"2606:4700::1", IPv6}, {"10.0.0.1", IPv4}, {"192.168.1.1", IPv4}, {"127.0.0.1", IPv4}, {"::1", IPv6}, {"fe80::1", IPv6}, {"2001:db8::1", IPv6}, {"9.9.9.9", IPv4}},
+			[]string{"9.9.9.9/32", "2606:4700::1/128"},
+		},
+		{
+			[]*IOC{{"2606:4700::", IPv6}, {"2606:4700::1", IPv6}, {"2606:4700::2", IPv6}, {"2606:4700::3", IPv6}},
+			[]string{"2606:4700::/126"},
+		},
+		{
+			[]*IOC{{"example.com", Domain}},
+			[]string{},
+		},
+	}
+
+	for i, test := range tests {
+		got := []string{}
+		for _, network := range AggregateIPs(test.input) {
+			got = append(got, network.String())
+		}
+		if strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("Test %d got %v wanted %v", i, got, test.want)
+		}
+	}
+}
+
+func TestFirewallBlocklists(t *testing.T) {
+	iocs := []*IOC{{"8.8.8.8", IPv4}, {"8.8.8.9", IPv4}, {"1.1.1.1", IPv4}, {"2606:4700::1", IPv6}}
+	options := FirewallOptions{Name: "bad"}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"cidr", PrintIOCsCIDR(iocs), "1.1.1.1/32\n8.8.8.8/31\n2606:4700::1/128"},
+		{"iptables", PrintIOCsIptables(iocs, options), strings.Join([]string{
+			"create bad hash:net family inet -exist",
+			"add bad 1.1.1.1/32 -exist",
+			"add bad 8.8.8.8/31 -exist",
+			"create bad6 hash:net family inet6 -exist",
+			"add bad6 2606:4700::1/128 -exist",
+		}, "\n")},
+		{"nftables", PrintIOCsNftables(iocs, options), `table inet bad {
+	set blocklist_v4 {
+		type ipv4_addr
+		flags interval
+		elements = { 1.1.1.1/32, 8.8.8.8/31 }
+	}
+	set blocklist_v6 {
+		type ipv6_addr
+		flags interval
+		elements = { 2606:4700::1/128 }
+	}
+}`},
+		{"pf", PrintIOCsPf(iocs, options), "table <bad> persist { \\\n\t1.1.1.1/32, \\\n\t8.8.8.8/31, \\\n\t2606:4700::1/128 \\\n}"},
+		{"cisco", PrintIOCsCiscoACL(iocs, options), strings.Join([]string{
+			"ip access-list extended bad",
+			" deny ip any host 1.1.1.1",
+			" deny ip host 1.1.1.1 any",
+			" deny ip any 8.8.8.8 0.0.0.1",
+			" deny ip 8.8.8.8 0.0.0.1 any",
+			" permit ip any any",
+			"ipv6 access-list bad6",
+			" deny ipv6 any 2606:4700::1/128",
+			" deny ipv6 2606:4700::1/128 any",
+			" permit ipv6 any any",
+		}, "\n")},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s got:\n%s\nwanted:\n%s", test.name, test.got, test.want)
+		}
+	}
+}
@@ -19,3 +19,65 @@ func uniqueStringSlice(slice []string) []string {
 
 	return strings
 }
+
+// uniqueNormalizedSlice Normalize each IOC of the type (see Normalize) and remove the duplicates, sorted
+func uniqueNormalizedSlice(slice []string, iocType Type) []string {
+	normalized := make([]string, 0, len(slice))
+	for _, value := range slice {
+		normalized = append(normalized, (&IOC{IOC: value, Type: iocType}).Normalize().IOC)
+	}
+	return uniqueStringSlice(normalized)
+}
+
+// containsIOC Check if the IOC is in iocs
+func containsIOC(iocs []*IOC, ioc *IOC) bool {
+	for _, other := range iocs {
+		if other.IOC == ioc.IOC && other.Type == ioc.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFoundIOC Check if the IOC was found, anywhere, in iocs
+func containsFoundIOC(iocs []*FoundIOC, ioc *IOC) bool {
+	for _, other := range iocs {
+		if other.IOC == ioc.IOC && other.Type == ioc.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFoundIOCInSource Check if the IOC was already found in the same source
+func containsFoundIOCInSource(iocs []*FoundIOC, ioc *FoundIOC) bool {
+	for _, other := range iocs {
+		if other.IOC == ioc.IOC && other.Type == ioc.Type && other.Source == ioc.Source {
+			return true
+		}
+	}
+	return false
+}
+
+// fangedValuesByType Get the unique fanged values of each type, in the order they appear
+func fangedValuesByType(iocs []*IOC) map[Type][]string {
+	values := map[Type][]string{}
+	seen := map[Type]map[string]bool{}
+	for _, ioc := range iocs {
+		value := ioc.Fang().IOC
+		if seen[ioc.Type] == nil {
+			seen[ioc.Type] = map[string]bool{}
+		}
+		if seen[ioc.Type][value] {
+			continue
+		}
+		seen[ioc.Type][value] = true
+		values[ioc.Type] = append(values[ioc.Type], value)
+	}
+	return values
+}
+
+// isHexDigit Check if the byte is a hex digit
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
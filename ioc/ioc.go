@@ -30,11 +30,26 @@ func ParseIOC(ioc string) *IOC {
 // GetIOCs Return a slice of IOCs from the provided data.
 // getFangedIOCs will also return IOCs that are fanged (ex: google.com).
 func GetIOCs(data string, getFangedIOCs bool) []*IOC {
+	return getIOCs(data, getFangedIOCs, false)
+}
+
+// GetIOCsNormalized Return a slice of normalized IOCs from the provided data (see Normalize),
+// so the same IOC written differently (ex: EVIL.com. and evil.com) is only returned once.
+func GetIOCsNormalized(data string, getFangedIOCs bool) []*IOC {
+	return getIOCs(data, getFangedIOCs, true)
+}
+
+func getIOCs(data string, getFangedIOCs bool, normalize bool) []*IOC {
 	var iocs []*IOC
 
 	// Loop through the types to find and search the provided data
 	for iocType, regex := range iocRegexes {
-		matches := uniqueStringSlice(regex.FindAllString(data, -1))
+		matches := regex.FindAllString(data, -1)
+		if normalize {
+			matches = uniqueNormalizedSlice(matches, iocType)
+		} else {
+			matches = uniqueStringSlice(matches)
+		}
 		for _, match := range matches {
 			ioc := &IOC{IOC: match, Type: iocType}
 
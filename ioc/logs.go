@@ -0,0 +1,328 @@
+package ioc
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Formats of structured logs
+const (
+	LogJSON     = "json" // JSON lines, concatenated JSON objects, or a JSON array of objects
+	LogCSV      = "csv"  // CSV or TSV with a header row
+	LogKeyValue = "kv"   // key=value pairs, ex: logfmt
+	LogZeek     = "zeek" // Zeek TSV or JSON logs
+	LogEVE      = "eve"  // Suricata EVE JSON events
+	LogSyslog   = "syslog"
+)
+
+// LogOptions Options used when getting IOCs from structured logs
+type LogOptions struct {
+	GetFangedIOCs bool
+	// Include Only search the fields matching one of these selectors, all fields are searched if empty.
+	// Selectors are field paths like JSONPath, ex: http.url, $.answers[*].data, $..src_ip (at any depth), or a CSV column name.
+	// Segments can have globs (ex: *_ip), and a selector matches the fields inside the fields it matches.
+	Include []string
+	// Exclude Skip the fields matching one of these selectors, even if they are included
+	Exclude []string
+}
+
+// maxLogLineSize Longest line of key=value pairs read
+const maxLogLineSize = 16 << 20
+
+// logField A field of a log record, with its path split in to segments to match selectors against
+type logField struct {
+	path     string
+	segments []string
+	value    string
+}
+
+// DetectLogFormat Guess the format of structured logs from their first line, one of the Log* constants
+func DetectLogFormat(line string) string {
+	line = strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(line, "#separator"):
+		return LogZeek
+	case strings.HasPrefix(line, "{") && strings.Contains(line, `"event_type"`):
+		return LogEVE
+	case strings.HasPrefix(line, "{") && (strings.Contains(line, `"_path"`) || strings.Contains(line, `"id.orig_h"`)):
+		return LogZeek
+	case strings.HasPrefix(line, "{") || strings.HasPrefix(line, "["):
+		return LogJSON
+	case syslog5424.MatchString(line) || syslog3164.MatchString(line):
+		return LogSyslog
+	case len(keyValuePair.FindAllString(line, -1)) >= 2:
+		return LogKeyValue
+	}
+	return LogCSV
+}
+
+// GetIOCsFromLogs Get the IOCs from each field of each record of structured logs, in one of the Log* formats (detected if empty).
+// Only the fields selected by the options are searched, and every IOC has the number of the record it was in (starting at 1)
+// as its "record" Metadata, and the path of the field (ex: http.request.headers[0]) as its "field".
+//
+// Zeek (conn, dns, http, ssl, files) and Suricata EVE logs only have their known fields turned in to IOCs of the field's type,
+// ex: dns.rrname is a Domain and fileinfo.sha256 a SHA256, instead of searching every field.  These are always fanged,
+// reserved IPs (private, loopback, etc) are skipped, and the Zeek log or EVE event type is the "log" Metadata.
+// Syslog messages (RFC 3164 and 5424) have their message and structured data searched, with their "host" and "app" as Metadata.
+//
+// If a record can not be parsed, the IOCs from the records before are returned with the error.
+func GetIOCsFromLogs(reader io.Reader, format string, options LogOptions) ([]*FoundIOC, error) {
+	buffered := bufio.NewReaderSize(reader, 1<<16)
+	firstLine := ""
+	for peek := 64; firstLine == "" && peek <= 1<<16; peek *= 4 {
+		data, _ := buffered.Peek(peek)
+		firstLine = strings.TrimLeft(string(data), " \t\r\n")
+		if end := strings.IndexByte(firstLine, '\n'); end != -1 {
+			firstLine = firstLine[:end]
+		} else if len(data) == peek {
+			// Keep peeking until the whole line is there
+			firstLine = ""
+		}
+	}
+	if format == "" {
+		format = DetectLogFormat(firstLine)
+	}
+
+	include, exclude := parseSelectors(options.Include), parseSelectors(options.Exclude)
+	iocs := []*FoundIOC{}
+	record := 0
+	handle := func(fields []logField, recordMetadata map[string]string) {
+		record++
+		selected := []logField{}
+		for _, field := range fields {
+			if (len(include) == 0 || matchSelectors(include, field.segments)) && !matchSelectors(exclude, field.segments) {
+				selected = append(selected, field)
+			}
+		}
+
+		var found []*FoundIOC
+		switch format {
+		case LogZeek, LogEVE:
+			found = sensorIOCs(selected, fields, format)
+			for _, field := range fields {
+				if field.path == "_path" || field.path == "event_type" {
+					recordMetadata = map[string]string{"log": field.value}
+				}
+			}
+		default:
+			for _, field := range selected {
+				fieldIOCs := GetIOCs(field.value, options.GetFangedIOCs)
+				sort.SliceStable(fieldIOCs, func(i, j int) bool { return fieldIOCs[i].Type < fieldIOCs[j].Type })
+				for _, ioc := range fieldIOCs {
+					found = append(found, &FoundIOC{IOC: ioc.IOC, Type: ioc.Type, Metadata: map[string]string{"field": field.path}})
+				}
+			}
+		}
+
+		for _, ioc := range found {
+			ioc.Metadata["record"] = strconv.Itoa(record)
+			for key, value := range recordMetadata {
+				ioc.Metadata[key] = value
+			}
+			iocs = append(iocs, ioc)
+		}
+	}
+
+	var err error
+	switch format {
+	case LogJSON, LogEVE:
+		err = readJSONLogs(buffered, handle)
+	case LogZeek:
+		if strings.HasPrefix(firstLine, "#") {
+			err = readZeekLogs(buffered, handle)
+		} else {
+			err = readJSONLogs(buffered, handle)
+		}
+	case LogCSV:
+		err = readCSVLogs(buffered, handle)
+	case LogKeyValue:
+		err = readKeyValueLogs(buffered, handle)
+	case LogSyslog:
+		err = readSyslog(buffered, handle)
+	default:
+		return nil, fmt.Errorf("unknown log format %s", format)
+	}
+	if err != nil {
+		return iocs, fmt.Errorf("record %d: %s", record+1, err)
+	}
+	return iocs, nil
+}
+
+// readJSONLogs Read each JSON object, or each object in a JSON array
+func readJSONLogs(reader io.Reader, handle func([]logField, map[string]string)) error {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	for {
+		var value interface{}
+		if err := decoder.Decode(&value); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if array, ok := value.([]interface{}); ok {
+			for _, element := range array {
+				handle(flattenJSON(element, "", nil, nil), nil)
+			}
+			continue
+		}
+		handle(flattenJSON(value, "", nil, nil), nil)
+	}
+}
+
+// flattenJSON Get the fields of a JSON value, with the paths of nested fields joined with dots, and array indexes in brackets
+func flattenJSON(value interface{}, prefix string, segments []string, fields []logField) []logField {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range value {
+			keys = append(keys, key)
+		}
+		// Maps are in a random order
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := value[key]
+			childPath := key
+			if prefix != "" {
+				childPath = prefix + "." + key
+			}
+			fields = flattenJSON(child, childPath, appendSegment(segments, strings.Split(key, ".")...), fields)
+		}
+	case []interface{}:
+		for i, child := range value {
+			fields = flattenJSON(child, fmt.Sprintf("%s[%d]", prefix, i), appendSegment(segments, strconv.Itoa(i)), fields)
+		}
+	case nil:
+	default:
+		fields = append(fields, logField{path: prefix, segments: segments, value: fmt.Sprint(value)})
+	}
+	return fields
+}
+
+// appendSegment Append to a copy of the segments, since siblings share the prefix.
+// Keys with dots (ex: Zeek's id.orig_h) are split so they match the same selectors as nested keys.
+func appendSegment(segments []string, add ...string) []string {
+	return append(append([]string{}, segments...), add...)
+}
+
+// readCSVLogs Read each row of a CSV (or TSV, if the header has tabs and no commas) with the columns named by the header
+func readCSVLogs(reader *bufio.Reader, handle func([]logField, map[string]string)) error {
+	csvReader := csv.NewReader(reader)
+	header, _ := reader.Peek(4096)
+	if line := strings.SplitN(string(header), "\n", 2)[0]; strings.Contains(line, "\t") && !strings.Contains(line, ",") {
+		csvReader.Comma = '\t'
+	}
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+
+	columns, err := csvReader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fields := []logField{}
+		for i, value := range row {
+			column := fmt.Sprintf("column%d", i+1)
+			if i < len(columns) && strings.TrimSpace(columns[i]) != "" {
+				column = strings.TrimSpace(columns[i])
+			}
+			fields = append(fields, logField{path: column, segments: strings.Split(column, "."), value: value})
+		}
+		handle(fields, nil)
+	}
+}
+
+// keyValuePair A key=value pair, the value can be quoted
+var keyValuePair = regexp.MustCompile(`([\w.\-]+)=("(?:[^"\\]|\\.)*"|'[^']*'|[^\s"']*)`)
+
+// readKeyValueLogs Read each line of key=value pairs, text that is not in a pair is skipped
+func readKeyValueLogs(reader io.Reader, handle func([]logField, map[string]string)) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		fields := []logField{}
+		for _, match := range keyValuePair.FindAllStringSubmatch(scanner.Text(), -1) {
+			value := match[2]
+			if unquoted, err := strconv.Unquote(value); err == nil && strings.HasPrefix(value, `"`) {
+				value = unquoted
+			} else if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+				value = value[1 : len(value)-1]
+			}
+			fields = append(fields, logField{path: match[1], segments: strings.Split(match[1], "."), value: value})
+		}
+		handle(fields, nil)
+	}
+	return scanner.Err()
+}
+
+// parseSelectors Split JSONPath-like selectors in to segments, ** is any number of segments (from ..)
+func parseSelectors(selectors []string) [][]string {
+	ret := [][]string{}
+	for _, selector := range selectors {
+		selector = strings.TrimPrefix(strings.TrimSpace(selector), "$")
+		selector = strings.NewReplacer("..", ".**.", "[", ".", "]", "").Replace(selector)
+		segments := []string{}
+		for _, segment := range strings.Split(selector, ".") {
+			if segment != "" {
+				segments = append(segments, strings.Trim(segment, `'"`))
+			}
+		}
+		if len(segments) > 0 {
+			ret = append(ret, segments)
+		}
+	}
+	return ret
+}
+
+// matchSelectors Check if a field (or a field it is inside of) is matched by any of the selectors
+func matchSelectors(selectors [][]string, segments []string) bool {
+	for _, selector := range selectors {
+		if matchSegments(selector, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(selector []string, segments []string) bool {
+	if len(selector) == 0 {
+		return true
+	}
+	if selector[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(selector[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(selector[0], segments[0]); !matched {
+		return false
+	}
+	return matchSegments(selector[1:], segments[1:])
+}
@@ -0,0 +1,131 @@
+package ioc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectLogFormat(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`{"ts": 1, "url": "http://evil.com"}`, LogJSON},
+		{`[{"ts": 1}]`, LogJSON},
+		{`ts=1 level=info msg="hello, world"`, LogKeyValue},
+		{`time,src_ip,dst_ip`, LogCSV},
+		{"time\tsrc_ip\tquery=name", LogCSV},
+	}
+	for _, test := range tests {
+		if got := DetectLogFormat(test.line); got != test.want {
+			t.Errorf("%s: got %s, wanted %s", test.line, got, test.want)
+		}
+	}
+}
+
+func TestGetIOCsFromLogs(t *testing.T) {
+	field := func(record, path string) map[string]string {
+		return map[string]string{"record": record, "field": path}
+	}
+	jsonLogs := `{"ts": 1583230953, "src_ip": "10[.]0[.]0[.]5", "http": {"url": "hxxp://evil[.]com/a", "headers": ["Referer: bad[.]net"]}, "note": "ignore me[.]com"}
+{"ts": 1583230954, "dns": {"answers": [{"name": "evil[.]com", "data": "1[.]2[.]3[.]4"}]}}
+`
+	tests := []struct {
+		name    string
+		logs    string
+		format  string
+		options LogOptions
+		want    []*FoundIOC
+	}{
+		{
+			"json all fields",
+			jsonLogs,
+			"",
+			LogOptions{Exclude: []string{"note", "http.url"}},
+			[]*FoundIOC{
+				{IOC: "bad[.]net", Type: Domain, Metadata: field("1", "http.headers[0]")},
+				{IOC: "10[.]0[.]0[.]5", Type: IPv4, Metadata: field("1", "src_ip")},
+				{IOC: "1[.]2[.]3[.]4", Type: IPv4, Metadata: field("2", "dns.answers[0].data")},
+				{IOC: "evil[.]com", Type: Domain, Metadata: field("2", "dns.answers[0].name")},
+			},
+		},
+		{
+			"json selectors",
+			jsonLogs,
+			LogJSON,
+			LogOptions{Include: []string{"$.http.url", "$..answers[*].data", "*_ip"}},
+			[]*FoundIOC{
+				{IOC: "evil[.]com", Type: Domain, Metadata: field("1", "http.url")},
+				{IOC: "hxxp://evil[.]com/a", Type: URL, Metadata: field("1", "http.url")},
+				{IOC: "10[.]0[.]0[.]5", Type: IPv4, Metadata: field("1", "src_ip")},
+				{IOC: "1[.]2[.]3[.]4", Type: IPv4, Metadata: field("2", "dns.answers[0].data")},
+			},
+		},
+		{
+			"json array",
+			`[{"a": "evil[.]com"}, {"b": ["x", "bad[.]net"]}]`,
+			"",
+			LogOptions{},
+			[]*FoundIOC{
+				{IOC: "evil[.]com", Type: Domain, Metadata: field("1", "a")},
+				{IOC: "bad[.]net", Type: Domain, Metadata: field("2", "b[1]")},
+			},
+		},
+		{
+			"csv",
+			"time,src ip,query\n1,1[.]2[.]3[.]4,evil[.]com\n2,\"5[.]6[.]7[.]8\",\"bad[.]net, x\"\n",
+			"",
+			LogOptions{Include: []string{"query", "src ip"}},
+			[]*FoundIOC{
+				{IOC: "1[.]2[.]3[.]4", Type: IPv4, Metadata: field("1", "src ip")},
+				{IOC: "evil[.]com", Type: Domain, Metadata: field("1", "query")},
+				{IOC: "5[.]6[.]7[.]8", Type: IPv4, Metadata: field("2", "src ip")},
+				{IOC: "bad[.]net", Type: Domain, Metadata: field("2", "query")},
+			},
+		},
+		{
+			"tsv",
+			"time\tquery\n1\tevil[.]com\n",
+			LogCSV,
+			LogOptions{},
+			[]*FoundIOC{{IOC: "evil[.]com", Type: Domain, Metadata: field("1", "query")}},
+		},
+		{
+			"key=value",
+			"ts=1 src=1[.]2[.]3[.]4 msg=\"visit evil[.]com now\"\n\nts=2 user='bad[.]net' other\n",
+			"",
+			LogOptions{Exclude: []string{"user"}},
+			[]*FoundIOC{
+				{IOC: "1[.]2[.]3[.]4", Type: IPv4, Metadata: field("1", "src")},
+				{IOC: "evil[.]com", Type: Domain, Metadata: field("1", "msg")},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := GetIOCsFromLogs(strings.NewReader(test.logs), test.format, test.options)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, wanted %v", test.name, got, test.want)
+			for _, ioc := range got {
+				t.Log(ioc.IOC, ioc.Type, ioc.Metadata)
+			}
+		}
+	}
+
+	// Invalid records return the IOCs from the records before
+	got, err := GetIOCsFromLogs(strings.NewReader("{\"a\": \"evil[.]com\"}\n{\"b\": "), LogJSON, LogOptions{})
+	if err == nil {
+		t.Errorf("Should have errored on invalid JSON")
+	}
+	if len(got) != 1 {
+		t.Errorf("Should have returned the IOCs from the first record, got %v", got)
+	}
+	if _, err := GetIOCsFromLogs(strings.NewReader(""), "xml", LogOptions{}); err == nil {
+		t.Errorf("Should have errored on an unknown format")
+	}
+}
@@ -0,0 +1,171 @@
+package ioc
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// defaultPorts Ports removed from URLs of the scheme
+var defaultPorts = map[string]string{"http": "80", "https": "443", "ftp": "21", "ws": "80", "wss": "443"}
+
+// Normalize Get the canonical form of an IOC, so the same indicator written differently is the same string.
+// Domains, hosts, and hashes are lowercased, trailing dots removed, IPs have their leading zeros removed (and IPv6 is compressed),
+// URLs are normalized (RFC 3986: lowercase scheme and host, default port removed, dot segments removed, percent encoding normalized),
+// and CVE, CWE, and CAPEC IDs are uppercased.  Defanged IOCs stay defanged, with the standard defangs.
+// Ex: EXAMPLE(.)com. -> example[.]com, HTTP://Example.com:80/a/../b -> http://example.com/b
+func (ioc *IOC) Normalize() *IOC {
+	defanged := !ioc.IsFanged()
+	ret := ioc.Fang()
+	value := strings.TrimSpace(ret.IOC)
+
+	switch ret.Type {
+	case MD5, SHA1, SHA256, SHA512, CPE:
+		value = strings.ToLower(value)
+	case Bitcoin:
+		// Only bech32 addresses are case insensitive
+		if strings.HasPrefix(strings.ToLower(value), "bc1") {
+			value = strings.ToLower(value)
+		}
+	case Domain:
+		value = normalizeHost(value)
+	case Email:
+		if at := strings.LastIndex(value, "@"); at != -1 {
+			value = strings.TrimSpace(value[:at]) + "@" + normalizeHost(strings.TrimSpace(value[at+1:]))
+		}
+	case IPv4, IPv6:
+		value = normalizeHost(value)
+	case URL:
+		value = normalizeURL(value)
+	case CVE, CWE, CAPEC:
+		value = strings.ToUpper(value)
+	}
+
+	ret.IOC = value
+	if defanged {
+		ret = ret.Defang()
+	}
+	return ret
+}
+
+// NormalizeIOCs Normalize each IOC (see Normalize), removing the IOCs that are duplicates once normalized.
+// IOCs are duplicates if they have the same type, source, and normalized value, and the first of them is kept.
+func NormalizeIOCs(iocs []*FoundIOC) []*FoundIOC {
+	ret := []*FoundIOC{}
+	seen := map[[3]string]bool{}
+	for _, ioc := range iocs {
+		normalized := ioc.with(ioc.ToIOC().Normalize())
+		key := [3]string{normalized.IOC, normalized.Type.String(), normalized.Source}
+		if !seen[key] {
+			seen[key] = true
+			ret = append(ret, normalized)
+		}
+	}
+	return ret
+}
+
+// normalizeHost Lowercase a domain and remove its trailing dot, or get the canonical form of an IP
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		if ip := net.ParseIP(host[1 : len(host)-1]); ip != nil {
+			return "[" + ip.String() + "]"
+		}
+		return host
+	}
+
+	// Leading zeros, ex: 010.001.002.003, which net.ParseIP does not accept
+	octets := strings.Split(host, ".")
+	if len(octets) == 4 {
+		for i, octet := range octets {
+			value, err := strconv.Atoi(octet)
+			if err != nil || value > 255 {
+				break
+			}
+			octets[i] = strconv.Itoa(value)
+			if i == 3 {
+				return strings.Join(octets, ".")
+			}
+		}
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return host
+}
+
+// normalizeURL Normalize a fanged URL, see Normalize
+func normalizeURL(value string) string {
+	separator := strings.Index(value, "://")
+	if separator == -1 {
+		return value
+	}
+	scheme := strings.ToLower(value[:separator])
+	rest := value[separator+3:]
+
+	fragment := ""
+	if i := strings.Index(rest, "#"); i != -1 {
+		rest, fragment = rest[:i], "#"+normalizePercentEncoding(rest[i+1:])
+	}
+	query := ""
+	if i := strings.Index(rest, "?"); i != -1 {
+		rest, query = rest[:i], "?"+normalizePercentEncoding(rest[i+1:])
+	}
+	authority, urlPath := rest, "/"
+	if i := strings.Index(rest, "/"); i != -1 {
+		authority, urlPath = rest[:i], removeDotSegments(normalizePercentEncoding(rest[i:]))
+	}
+
+	userinfo := ""
+	if i := strings.LastIndex(authority, "@"); i != -1 {
+		userinfo, authority = authority[:i+1], authority[i+1:]
+	}
+	host, port := authority, ""
+	if i := strings.LastIndex(authority, ":"); i != -1 && i > strings.LastIndex(authority, "]") {
+		host, port = authority[:i], authority[i+1:]
+	}
+	host = normalizeHost(host)
+	if port != "" && port != defaultPorts[scheme] {
+		host += ":" + port
+	}
+
+	return scheme + "://" + userinfo + host + urlPath + query + fragment
+}
+
+// normalizePercentEncoding Uppercase the hex of percent encoded bytes, and decode the characters that never need encoding (RFC 3986 2.3)
+func normalizePercentEncoding(value string) string {
+	return percentEncoded.ReplaceAllStringFunc(value, func(encoded string) string {
+		b, _ := strconv.ParseUint(encoded[1:], 16, 8)
+		c := byte(b)
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			return string([]byte{c})
+		}
+		return strings.ToUpper(encoded)
+	})
+}
+
+// removeDotSegments Resolve the . and .. segments of a URL path (RFC 3986 5.2.4)
+func removeDotSegments(urlPath string) string {
+	output := []string{}
+	segments := strings.Split(urlPath, "/")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch segment {
+		case ".":
+			if last {
+				output = append(output, "")
+			}
+		case "..":
+			// Never remove the empty segment before the first /
+			if len(output) > 1 {
+				output = output[:len(output)-1]
+			}
+			if last {
+				output = append(output, "")
+			}
+		default:
+			output = append(output, segment)
+		}
+	}
+	return strings.Join(output, "/")
+}
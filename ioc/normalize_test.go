@@ -0,0 +1,64 @@
+package ioc
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		ioc  *IOC
+		want string
+	}{
+		{&IOC{IOC: "EXAMPLE.com.", Type: Domain}, "example.com"},
+		{&IOC{IOC: "EXAMPLE(.)com", Type: Domain}, "example[.]com"},
+		{&IOC{IOC: "John@EVIL.com.", Type: Email}, "John@evil.com"},
+		{&IOC{IOC: "010.001.002.003", Type: IPv4}, "10.1.2.3"},
+		{&IOC{IOC: "2001:DB8:0:0::1", Type: IPv6}, "2001:db8::1"},
+		{&IOC{IOC: "874058E8D8582BF85C115CE319C5B0AF", Type: MD5}, "874058e8d8582bf85c115ce319c5b0af"},
+		{&IOC{IOC: "cve-2020-1234", Type: CVE}, "CVE-2020-1234"},
+		{&IOC{IOC: "cwe-79", Type: CWE}, "CWE-79"},
+		{&IOC{IOC: "capec-66", Type: CAPEC}, "CAPEC-66"},
+		{&IOC{IOC: "1BoatSLRHtKNngkdXEeobR76b53LETtpyT", Type: Bitcoin}, "1BoatSLRHtKNngkdXEeobR76b53LETtpyT"},
+		{&IOC{IOC: "HTTP://Example.com:80/a/../b", Type: URL}, "http://example.com/b"},
+		{&IOC{IOC: "http://example.com", Type: URL}, "http://example.com/"},
+		{&IOC{IOC: "https://user@Example.com.:8443/a/./b/..?q=%2a%7e#Top", Type: URL}, "https://user@example.com:8443/a/?q=%2A~#Top"},
+		{&IOC{IOC: "hxxps[://]Ex[.]com:443/%7euser/%2f", Type: URL}, "hxxps[://]ex[.]com/~user/%2F"},
+		{&IOC{IOC: "http://[2001:DB8::1]:80/x", Type: URL}, "http://[2001:db8::1]/x"},
+	}
+	for _, test := range tests {
+		if got := test.ioc.Normalize(); got.IOC != test.want || got.Type != test.ioc.Type {
+			t.Errorf("%s: got %s, wanted %s", test.ioc.IOC, got.IOC, test.want)
+		}
+	}
+}
+
+func TestNormalizeIOCs(t *testing.T) {
+	iocs := []*FoundIOC{
+		{IOC: "EVIL.com", Type: Domain},
+		{IOC: "evil.com.", Type: Domain},
+		{IOC: "evil.com", Type: Domain, Source: "b.txt"},
+		{IOC: "evil(.)com", Type: Domain},
+		{IOC: "evil[.]com", Type: Domain},
+	}
+	want := []*FoundIOC{
+		{IOC: "evil.com", Type: Domain},
+		{IOC: "evil.com", Type: Domain, Source: "b.txt"},
+		{IOC: "evil[.]com", Type: Domain},
+	}
+	if got := NormalizeIOCs(iocs); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+
+	got := GetIOCsNormalized("EVIL[.]com and evil(.)COM, CVE-2020-1234 and cve-2020-1234, hxxp://Evil[.]com:80/a/../b", false)
+	sort.SliceStable(got, func(i, j int) bool { return got[i].Type < got[j].Type })
+	wantIOCs := []*IOC{
+		{IOC: "evil[.]com", Type: Domain},
+		{IOC: "hxxp[://]evil[.]com/b", Type: URL},
+		{IOC: "CVE-2020-1234", Type: CVE},
+	}
+	if !reflect.DeepEqual(got, wantIOCs) {
+		t.Errorf("got %v, wanted %v", got, wantIOCs)
+	}
+}
@@ -0,0 +1,150 @@
+package ioc
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// officeTextParts The parts of DOCX, XLSX, and PPTX files that have the text of the document, its comments, and notes
+var officeTextParts = regexp.MustCompile(`^(` +
+	`word/(document|header\d*|footer\d*|footnotes|endnotes|comments\w*)|` +
+	`xl/(sharedStrings|worksheets/sheet\d+|comments\d*|threadedComments/\w+)|` +
+	`ppt/(slides/slide\d+|notesSlides/notesSlide\d+|comments/\w+)` +
+	`)\.xml$`)
+
+// officeRelationships The relationship parts, which have the targets of hyperlinks
+var officeRelationships = regexp.MustCompile(`^(word|xl|ppt)/(.+/)?_rels/[^/]+\.rels$`)
+
+// GetIOCsFromOffice Get the IOCs from an Office Open XML document (DOCX, XLSX, PPTX).
+// The text of the document, spreadsheet cells, slides, notes, and comments are searched.
+// The targets of external hyperlinks are always included, even though they are fanged.
+func GetIOCsFromOffice(reader io.ReaderAt, size int64) ([]*IOC, error) {
+	return getIOCsFromOffice(reader, size, newContentExtractor(ContentOptions{}))
+}
+
+func getIOCsFromOffice(reader io.ReaderAt, size int64, e *contentExtractor) ([]*IOC, error) {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, err
+	}
+
+	text := new(strings.Builder)
+	links := new(strings.Builder)
+	found := false
+	for _, file := range zipReader.File {
+		isText, isRels := officeTextParts.MatchString(file.Name), officeRelationships.MatchString(file.Name)
+		if !isText && !isRels {
+			continue
+		}
+		found = true
+
+		part, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Name, err)
+		}
+		data, err := e.read(part)
+		part.Close()
+		if err == nil && isText {
+			err = officeText(bytes.NewReader(data), text)
+		} else if err == nil {
+			err = officeLinks(bytes.NewReader(data), links)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Name, err)
+		}
+		text.WriteString("\n")
+	}
+	if !found {
+		return nil, fmt.Errorf("not an Office Open XML document")
+	}
+
+	iocs := GetIOCs(text.String(), e.options.GetFangedIOCs)
+	for _, link := range GetIOCs(links.String(), true) {
+		if !containsIOC(iocs, link) {
+			iocs = append(iocs, link)
+		}
+	}
+	return iocs, nil
+}
+
+// officeText Write the text of a part, with a newline after each paragraph, row, or cell
+func officeText(reader io.Reader, text *strings.Builder) error {
+	decoder := xml.NewDecoder(reader)
+	inText, inFormula := false, false
+	cellType := ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			// Text of documents, slides, shared strings, formulas, and field codes (ex: HYPERLINK "url")
+			case "t", "text":
+				inText = true
+			case "instrText", "f":
+				inText, inFormula = true, true
+			case "v":
+				// Cells of type s have the index of a shared string as their value
+				inText = cellType != "s"
+			case "c":
+				cellType = ""
+				for _, attr := range token.Attr {
+					if attr.Name.Local == "t" {
+						cellType = attr.Value
+					}
+				}
+			case "tab":
+				text.WriteString("\t")
+			case "br", "cr":
+				text.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch token.Name.Local {
+			case "t", "text", "v":
+				inText = false
+			case "instrText", "f":
+				inText, inFormula = false, false
+				text.WriteString(" ")
+			case "p", "tc", "tr", "si", "c", "row", "comment":
+				text.WriteString("\n")
+			}
+		case xml.CharData:
+			if inFormula {
+				// Split up quoted arguments, ex: HYPERLINK("url","name")
+				text.WriteString(strings.ReplaceAll(string(token), `"`, " "))
+			} else if inText {
+				text.Write(token)
+			}
+		}
+	}
+}
+
+// officeLinks Write the target of each external relationship on its own line
+func officeLinks(reader io.Reader, links *strings.Builder) error {
+	relationships := struct {
+		Relationships []struct {
+			Target     string `xml:",attr"`
+			TargetMode string `xml:",attr"`
+		} `xml:"Relationship"`
+	}{}
+	if err := xml.NewDecoder(reader).Decode(&relationships); err != nil {
+		return err
+	}
+	for _, relationship := range relationships.Relationships {
+		if relationship.TargetMode == "External" {
+			links.WriteString(relationship.Target + "\n")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package ioc
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// buildZip Build a zip of the files, in order
+func buildZip(files [][2]string) []byte {
+	ret := new(bytes.Buffer)
+	w := zip.NewWriter(ret)
+	for _, file := range files {
+		f, _ := w.Create(file[0])
+		f.Write([]byte(file[1]))
+	}
+	w.Close()
+	return ret.Bytes()
+}
+
+const officeContentTypes = `<?xml version="1.0" encoding="UTF-8"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"></Types>`
+
+func TestGetIOCsFromOffice(t *testing.T) {
+	tests := []struct {
+		name  string
+		files [][2]string
+		want  []*IOC
+	}{
+		{
+			"docx",
+			[][2]string{
+				{"[Content_Types].xml", officeContentTypes},
+				{"word/document.xml", `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><w:body>
+<w:p><w:r><w:t>C2 is evil[.]</w:t></w:r><w:r><w:t>com</w:t></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>1[.]2[.]3[.]4</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>874058e8d8582bf85c115ce319c5b0af</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+<w:p><w:hyperlink r:id="rId5"><w:r><w:t>click here</w:t></w:r></w:hyperlink></w:p>
+</w:body></w:document>`},
+				{"word/_rels/document.xml.rels", `<?xml version="1.0" encoding="UTF-8"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+<Relationship Id="rId5" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="http://hidden.org/payload" TargetMode="External"/>
+</Relationships>`},
+				{"word/comments.xml", `<w:comments xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:comment><w:p><w:r><w:t>also bad[.]net</w:t></w:r></w:p></w:comment></w:comments>`},
+				{"word/styles.xml", `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:t>ignored[.]com</w:t></w:styles>`},
+			},
+			[]*IOC{
+				{IOC: "874058e8d8582bf85c115ce319c5b0af", Type: MD5},
+				{IOC: "bad[.]net", Type: Domain},
+				{IOC: "evil[.]com", Type: Domain},
+				{IOC: "hidden.org", Type: Domain},
+				{IOC: "1[.]2[.]3[.]4", Type: IPv4},
+				{IOC: "http://hidden.org/payload", Type: URL},
+			},
+		},
+		{
+			"xlsx",
+			[][2]string{
+				{"[Content_Types].xml", officeContentTypes},
+				{"xl/sharedStrings.xml", `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>evil[.]com</t></si><si><r><t>bad</t></r><r><t>[.]net</t></r></si></sst>`},
+				{"xl/worksheets/sheet1.xml", `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="inlineStr"><is><t>1[.]2[.]3[.]4</t></is></c></row>
+<row r="2"><c r="A2" t="str"><f>HYPERLINK("hxxp://formula[.]org/x","link")</f><v>link</v></c></row>
+</sheetData></worksheet>`},
+			},
+			[]*IOC{
+				{IOC: "bad[.]net", Type: Domain},
+				{IOC: "evil[.]com", Type: Domain},
+				{IOC: "formula[.]org", Type: Domain},
+				{IOC: "1[.]2[.]3[.]4", Type: IPv4},
+				{IOC: "hxxp://formula[.]org/x", Type: URL},
+			},
+		},
+		{
+			"pptx",
+			[][2]string{
+				{"[Content_Types].xml", officeContentTypes},
+				{"ppt/slides/slide1.xml", `<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"><p:cSld><p:spTree><p:sp><p:txBody><a:p><a:r><a:t>CVE-2020-0601</a:t></a:r></a:p></p:txBody></p:sp></p:spTree></p:cSld></p:sld>`},
+				{"ppt/notesSlides/notesSlide1.xml", `<p:notes xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"><a:p><a:r><a:t>see evil[.]com</a:t></a:r></a:p></p:notes>`},
+			},
+			[]*IOC{
+				{IOC: "evil[.]com", Type: Domain},
+				{IOC: "CVE-2020-0601", Type: CVE},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		data := buildZip(test.files)
+		if got := DetectContentType(data); got != ContentOffice {
+			t.Errorf("%s: detected as %s", test.name, got)
+		}
+		got, err := GetIOCsFromOffice(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		// GetIOCs finds types in a random order
+		sort.SliceStable(got, func(i, j int) bool { return got[i].Type < got[j].Type })
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, wanted %v", test.name, got, test.want)
+		}
+	}
+
+	// A zip that is not a document
+	data := buildZip([][2]string{{"readme.txt", "evil[.]com"}})
+	if _, err := GetIOCsFromOffice(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Errorf("Should have errored on a zip that is not a document")
+	}
+}
@@ -0,0 +1,170 @@
+package ioc
+
+import (
+	"crypto/rand"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// OpenIOCOptions Metadata to include in an OpenIOC document
+type OpenIOCOptions struct {
+	ID          string // Defaults to a random UUID
+	Author      string
+	Description string
+	Date        time.Time // Defaults to now
+}
+
+// openIOCItem How a Type maps to an OpenIOC IndicatorItem
+type openIOCItem struct {
+	document    string
+	search      string
+	contentType string
+}
+
+// openIOCItems OpenIOC indicator items for each type we can export.
+// Types not in this map have no OpenIOC equivalent and are skipped.
+var openIOCItems = map[Type]openIOCItem{
+	MD5:    {"FileItem", "FileItem/Md5sum", "md5"},
+	SHA1:   {"FileItem", "FileItem/Sha1sum", "sha1"},
+	SHA256: {"FileItem", "FileItem/Sha256sum", "sha256"},
+	Domain: {"Network", "Network/DNS", "string"},
+	Email:  {"Email", "Email/From", "string"},
+	IPv4:   {"PortItem", "PortItem/remoteIP", "IP"},
+	IPv6:   {"PortItem", "PortItem/remoteIP", "IP"},
+	URL:    {"UrlHistoryItem", "UrlHistoryItem/URL", "string"},
+	File:   {"FileItem", "FileItem/FileName", "string"},
+}
+
+const openIOCDateFormat = "2006-01-02T15:04:05"
+
+// -- OpenIOC 1.1 document structure --
+
+type openIOCDocument struct {
+	XMLName      xml.Name         `xml:"OpenIOC"`
+	Xmlns        string           `xml:"xmlns,attr"`
+	ID           string           `xml:"id,attr"`
+	LastModified string           `xml:"last-modified,attr"`
+	Published    string           `xml:"published-date,attr"`
+	Metadata     openIOCMetadata  `xml:"metadata"`
+	Criteria     openIOCIndicator `xml:"criteria>Indicator"`
+	Parameters   struct{}         `xml:"parameters"`
+}
+
+type openIOCMetadata struct {
+	ShortDescription string   `xml:"short_description"`
+	Description      string   `xml:"description"`
+	Keywords         string   `xml:"keywords"`
+	AuthoredBy       string   `xml:"authored_by"`
+	AuthoredDate     string   `xml:"authored_date"`
+	Links            struct{} `xml:"links"`
+}
+
+type openIOCIndicator struct {
+	ID       string                 `xml:"id,attr"`
+	Operator string                 `xml:"operator,attr"`
+	Items    []openIOCIndicatorItem `xml:"IndicatorItem"`
+}
+
+type openIOCIndicatorItem struct {
+	ID           string         `xml:"id,attr"`
+	Condition    string         `xml:"condition,attr"`
+	PreserveCase bool           `xml:"preserve-case,attr"`
+	Negate       bool           `xml:"negate,attr"`
+	Context      openIOCContext `xml:"Context"`
+	Content      openIOCContent `xml:"Content"`
+}
+
+type openIOCContext struct {
+	Document string `xml:"document,attr"`
+	Search   string `xml:"search,attr"`
+	Type     string `xml:"type,attr"`
+}
+
+type openIOCContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// PrintIOCsOpenIOC Takes []IOC and returns an OpenIOC 1.1 document with all the IOCs OR-ed together.
+// IOCs are fanged since OpenIOC consumers match on the real values.
+// Types with no OpenIOC equivalent (Bitcoin, SHA512, CVE, etc) are skipped, and the short description is the number of IOCs included.
+// Errors if random IDs can not be generated.
+func PrintIOCsOpenIOC(iocs []*IOC, options OpenIOCOptions) (string, error) {
+	if options.ID == "" {
+		id, err := newUUID()
+		if err != nil {
+			return "", err
+		}
+		options.ID = id
+	}
+	if options.Date.IsZero() {
+		options.Date = time.Now()
+	}
+	date := options.Date.UTC().Format(openIOCDateFormat)
+
+	doc := openIOCDocument{
+		Xmlns:        "http://openioc.org/schemas/OpenIOC_1.1",
+		ID:           options.ID,
+		LastModified: date,
+		Published:    date,
+		Metadata: openIOCMetadata{
+			Description:  options.Description,
+			AuthoredBy:   options.Author,
+			AuthoredDate: date,
+		},
+		Criteria: openIOCIndicator{
+			Operator: "OR",
+		},
+	}
+	var err error
+	if doc.Criteria.ID, err = newUUID(); err != nil {
+		return "", err
+	}
+
+	for _, ioc := range iocs {
+		item, ok := openIOCItems[ioc.Type]
+		if !ok {
+			continue
+		}
+		id, err := newUUID()
+		if err != nil {
+			return "", err
+		}
+		doc.Criteria.Items = append(doc.Criteria.Items, openIOCIndicatorItem{
+			ID:        id,
+			Condition: "is",
+			Context:   openIOCContext{Document: item.document, Search: item.search, Type: "mir"},
+			Content:   openIOCContent{Type: item.contentType, Value: ioc.Fang().IOC},
+		})
+	}
+
+	doc.Metadata.ShortDescription = fmt.Sprintf("%d IOCs", len(doc.Criteria.Items))
+	if len(doc.Criteria.Items) == 1 {
+		doc.Metadata.ShortDescription = "1 IOC"
+	}
+
+	// Marshalling our own structures can not fail
+	out, _ := xml.MarshalIndent(doc, "", "  ")
+	return xml.Header + string(out), nil
+}
+
+// newUUID Generate a random (version 4) UUID
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating a UUID: %s", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// mustNewUUID Generate a random UUID, panicking if the system's random source fails
+func mustNewUUID() string {
+	id, err := newUUID()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
@@ -0,0 +1,63 @@
+package ioc
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrintIOCsOpenIOC(t *testing.T) {
+	iocs := []*IOC{
+		{"874058e8d8582bf85c115ce319c5b0af", MD5},
+		{"example[.]com", Domain},
+		{"1[.]2[.]3[.]4", IPv4},
+		{"hxxp[://]example[.]com/path", URL},
+		{"CVE-2016-0000", CVE},
+	}
+	date := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	out, err := PrintIOCsOpenIOC(iocs, OpenIOCOptions{ID: "test-id", Author: "tester", Description: "test report", Date: date})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(out, xml.Header) {
+		t.Errorf("Missing xml header")
+	}
+
+	doc := openIOCDocument{}
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("Produced invalid xml: %s", err)
+	}
+
+	if doc.ID != "test-id" || doc.Metadata.AuthoredBy != "tester" || doc.Metadata.Description != "test report" {
+		t.Errorf("Incorrect metadata %+v", doc.Metadata)
+	}
+	if doc.Metadata.ShortDescription != "4 IOCs" {
+		t.Errorf("Incorrect short description %s", doc.Metadata.ShortDescription)
+	}
+	if doc.Metadata.AuthoredDate != "2020-03-01T12:00:00" {
+		t.Errorf("Incorrect date %s", doc.Metadata.AuthoredDate)
+	}
+	if doc.Criteria.Operator != "OR" {
+		t.Errorf("Indicators should be OR-ed")
+	}
+
+	want := []struct {
+		search string
+		value  string
+	}{
+		{"FileItem/Md5sum", "874058e8d8582bf85c115ce319c5b0af"},
+		{"Network/DNS", "example.com"},
+		{"PortItem/remoteIP", "1.2.3.4"},
+		{"UrlHistoryItem/URL", "http://example.com/path"},
+	}
+	if len(doc.Criteria.Items) != len(want) {
+		t.Fatalf("Expected %d indicator items, got %d", len(want), len(doc.Criteria.Items))
+	}
+	for i, item := range doc.Criteria.Items {
+		if item.Context.Search != want[i].search || item.Content.Value != want[i].value {
+			t.Errorf("Expected %s=%s but got %s=%s", want[i].search, want[i].value, item.Context.Search, item.Content.Value)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package ioc
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"net"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// ParsedIOC A value parsed as a single IOC, with its forms and the results of validating it, see Parse
+type ParsedIOC struct {
+	// Input The value that was parsed
+	Input string
+	// IOC The IOC in the input, Unknown if there is none
+	IOC      *IOC
+	Fanged   string
+	Defanged string
+	// Valid If the whole input is the IOC, and it passes the checks of its type
+	Valid bool
+	// Checks The results of validating the IOC, ex: "checksum": "valid" for Bitcoin addresses,
+	// "public_suffix" and "registered_domain" for domains, and "class" (public, private, loopback, etc) for IPs
+	Checks map[string]string
+	// URL The components of a URL IOC, nil for other types
+	URL *URLComponents
+}
+
+// ipClasses Special purpose networks, anything else is public
+var ipClasses = []struct {
+	class    string
+	networks []*net.IPNet
+}{
+	{"unspecified", parseCIDRs("0.0.0.0/8", "::/128")},
+	{"loopback", parseCIDRs("127.0.0.0/8", "::1/128")},
+	{"private", parseCIDRs("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7")},
+	{"shared", parseCIDRs("100.64.0.0/10")},
+	{"link-local", parseCIDRs("169.254.0.0/16", "fe80::/10")},
+	{"documentation", parseCIDRs("192.0.2.0/24", "198.51.100.0/24", "203.0.113.0/24", "2001:db8::/32")},
+	{"benchmarking", parseCIDRs("198.18.0.0/15")},
+	{"multicast", parseCIDRs("224.0.0.0/4", "ff00::/8")},
+	{"reserved", parseCIDRs("240.0.0.0/4", "192.0.0.0/24", "192.88.99.0/24", "64:ff9b::/96", "100::/64", "2001::/23")},
+}
+
+// Parse Parse a value as a single IOC (see ParseIOC), getting its fanged and defanged forms and validating it.
+// The IOC is the longest one in the value, so a URL with a file name in it is a URL, not a File.
+func Parse(value string) *ParsedIOC {
+	value = strings.TrimSpace(value)
+	ret := &ParsedIOC{Input: value, Checks: map[string]string{}}
+	// Defanged IOCs can be split up by the regexes, ex: john[AT]evil[.]com is an email and a domain
+	rewriteIOCs(value, func(ioc *IOC) string {
+		if ioc.IOC == value {
+			ret.IOC = ioc
+		}
+		return ioc.IOC
+	})
+	for _, ioc := range GetIOCs(value, true) {
+		if ret.IOC != nil && ret.IOC.IOC == value {
+			break
+		}
+		if ret.IOC == nil || len(ioc.IOC) > len(ret.IOC.IOC) || len(ioc.IOC) == len(ret.IOC.IOC) && ioc.Type > ret.IOC.Type {
+			ret.IOC = ioc
+		}
+	}
+	if ret.IOC == nil {
+		ret.IOC = &IOC{IOC: value, Type: Unknown}
+	}
+	ret.Fanged = ret.IOC.Fang().IOC
+	ret.Defanged = ret.IOC.Fang().Defang().IOC
+	if ret.IOC.Type == Unknown {
+		return ret
+	}
+
+	ret.Checks["match"] = "exact"
+	if ret.IOC.IOC != value {
+		ret.Checks["match"] = "partial"
+	}
+	ret.Valid = validateIOC(ret.IOC.Type, ret.Fanged, ret.Checks) && ret.Checks["match"] == "exact"
+	if ret.IOC.Type == URL {
+		ret.URL, _ = ret.IOC.ParseURL()
+	}
+	return ret
+}
+
+// validateIOC Check a fanged IOC of the type, adding the results to the checks
+func validateIOC(iocType Type, ioc string, checks map[string]string) bool {
+	switch iocType {
+	case Bitcoin:
+		valid := validBitcoinAddress(ioc)
+		checks["checksum"] = "invalid"
+		if valid {
+			checks["checksum"] = "valid"
+		}
+		return valid
+	case MD5, SHA1, SHA256, SHA512:
+		checks["bits"] = strconv.Itoa(len(ioc) * 4)
+		return true
+	case Domain:
+		return validateHost(ioc, checks)
+	case Email:
+		at := strings.LastIndex(ioc, "@")
+		if at == -1 {
+			return false
+		}
+		checks["user"] = strings.TrimSpace(ioc[:at])
+		return validateHost(strings.TrimSpace(ioc[at+1:]), checks)
+	case IPv4, IPv6:
+		return validateHost(ioc, checks)
+	case URL:
+		parsed, err := url.Parse(ioc)
+		if err != nil {
+			checks["error"] = err.Error()
+			return false
+		}
+		checks["scheme"] = parsed.Scheme
+		if parsed.Hostname() == "" {
+			return false
+		}
+		return validateHost(parsed.Hostname(), checks)
+	case File:
+		checks["extension"] = strings.TrimPrefix(path.Ext(ioc), ".")
+		return true
+	case CVE:
+		year, _ := strconv.Atoi(strings.Split(ioc, "-")[1])
+		checks["year"] = strconv.Itoa(year)
+		// CVE IDs started in 1999
+		return year >= 1999
+	}
+	return true
+}
+
+// validateHost Check a domain has a known public suffix, or get the class of an IP
+func validateHost(host string, checks map[string]string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		checks["class"] = "public"
+		for _, class := range ipClasses {
+			for _, network := range class.networks {
+				if network.Contains(ip) {
+					checks["class"] = class.class
+				}
+			}
+		}
+		return true
+	}
+	if strings.Contains(host, ":") {
+		checks["class"] = "invalid"
+		return false
+	}
+
+	suffix, icann := publicsuffix.PublicSuffix(host)
+	checks["public_suffix"] = suffix
+	// Suffixes that are not in the list are only the last label, and are not ICANN
+	if !icann && !strings.Contains(suffix, ".") {
+		checks["public_suffix"] = "unknown"
+		return false
+	}
+	registered, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		// The domain is a public suffix itself
+		return false
+	}
+	checks["registered_domain"] = registered
+	return true
+}
+
+// validBitcoinAddress Check the checksum of a base58 (legacy and P2SH) or bech32/bech32m (segwit) Bitcoin address
+func validBitcoinAddress(address string) bool {
+	if strings.HasPrefix(strings.ToLower(address), "bc1") {
+		return validBech32(address)
+	}
+
+	const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	value := new(big.Int)
+	for _, c := range address {
+		digit := strings.IndexRune(alphabet, c)
+		if digit == -1 {
+			return false
+		}
+		value.Mul(value, big.NewInt(58))
+		value.Add(value, big.NewInt(int64(digit)))
+	}
+	decoded := value.Bytes()
+	// Leading 1s are leading zero bytes
+	for i := 0; i < len(address) && address[i] == '1'; i++ {
+		decoded = append([]byte{0}, decoded...)
+	}
+	if len(decoded) != 25 {
+		return false
+	}
+	first := sha256.Sum256(decoded[:21])
+	second := sha256.Sum256(first[:])
+	return string(second[:4]) == string(decoded[21:])
+}
+
+// validBech32 Check the checksum of a bech32 (BIP 173) or bech32m (BIP 350) address
+func validBech32(address string) bool {
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return false
+	}
+	address = strings.ToLower(address)
+	separator := strings.LastIndex(address, "1")
+	if separator < 1 || separator+7 > len(address) {
+		return false
+	}
+
+	const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	values := []int{}
+	for _, c := range address[:separator] {
+		values = append(values, int(c)>>5)
+	}
+	values = append(values, 0)
+	for _, c := range address[:separator] {
+		values = append(values, int(c)&31)
+	}
+	for _, c := range address[separator+1:] {
+		value := strings.IndexRune(charset, c)
+		if value == -1 {
+			return false
+		}
+		values = append(values, value)
+	}
+
+	generator := []int{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	checksum := 1
+	for _, value := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ value
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+	return checksum == 1 || checksum == 0x2bc830a3
+}
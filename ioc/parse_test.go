@@ -0,0 +1,46 @@
+package ioc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value    string
+		iocType  Type
+		fanged   string
+		defanged string
+		valid    bool
+		checks   map[string]string
+	}{
+		{"hxxp://evil[.]com/a.exe", URL, "http://evil.com/a.exe", "hxxp[://]evil[.]com/a[.]exe", true,
+			map[string]string{"match": "exact", "scheme": "http", "public_suffix": "com", "registered_domain": "evil.com"}},
+		{" john[AT]evil[.]co.uk ", Email, "john@evil.co.uk", "john[AT]evil[.]co[.]uk", true,
+			map[string]string{"match": "exact", "user": "john", "public_suffix": "co.uk", "registered_domain": "evil.co.uk"}},
+		{"see evil.blogspot.com", Domain, "evil.blogspot.com", "evil[.]blogspot[.]com", false,
+			map[string]string{"match": "partial", "public_suffix": "blogspot.com", "registered_domain": "evil.blogspot.com"}},
+		{"192.168.1.1", IPv4, "192.168.1.1", "192[.]168[.]1[.]1", true, map[string]string{"match": "exact", "class": "private"}},
+		{"2001:db8::1", IPv6, "2001:db8::1", "2001[:]db8[:][:]1", true, map[string]string{"match": "exact", "class": "documentation"}},
+		{"1BoatSLRHtKNngkdXEeobR76b53LETtpyT", Bitcoin, "1BoatSLRHtKNngkdXEeobR76b53LETtpyT", "1BoatSLRHtKNngkdXEeobR76b53LETtpyT", true,
+			map[string]string{"match": "exact", "checksum": "valid"}},
+		{"1BoatSLRHtKNngkdXEeobR76b53LETtpyX", Bitcoin, "1BoatSLRHtKNngkdXEeobR76b53LETtpyX", "1BoatSLRHtKNngkdXEeobR76b53LETtpyX", false,
+			map[string]string{"match": "exact", "checksum": "invalid"}},
+		{"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", Bitcoin, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", true,
+			map[string]string{"match": "exact", "checksum": "valid"}},
+		{"874058E8D8582BF85C115CE319C5B0AF", MD5, "874058E8D8582BF85C115CE319C5B0AF", "874058E8D8582BF85C115CE319C5B0AF", true,
+			map[string]string{"match": "exact", "bits": "128"}},
+		{"CVE-2020-1234", CVE, "CVE-2020-1234", "CVE-2020-1234", true, map[string]string{"match": "exact", "year": "2020"}},
+		{"hello", Unknown, "hello", "hello", false, map[string]string{}},
+	}
+	for _, test := range tests {
+		got := Parse(test.value)
+		if got.IOC.Type != test.iocType || got.Fanged != test.fanged || got.Defanged != test.defanged || got.Valid != test.valid {
+			t.Errorf("%s: got %s %s %s %t, wanted %s %s %s %t", test.value,
+				got.IOC.Type, got.Fanged, got.Defanged, got.Valid, test.iocType, test.fanged, test.defanged, test.valid)
+		}
+		if !reflect.DeepEqual(got.Checks, test.checks) {
+			t.Errorf("%s: got checks %v, wanted %v", test.value, got.Checks, test.checks)
+		}
+	}
+}
@@ -0,0 +1,594 @@
+package ioc
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPacketSize Largest packet or pcapng block read, anything bigger means the capture is corrupt
+const maxPacketSize = 16 << 20
+
+// Link types of the packets that can be decoded
+const (
+	linkTypeNull      = 0
+	linkTypeEthernet  = 1
+	linkTypeRaw       = 101
+	linkTypeLoop      = 108
+	linkTypeLinuxSLL  = 113
+	linkTypeIPv4      = 228
+	linkTypeIPv6      = 229
+	linkTypeLinuxSLL2 = 276
+)
+
+// pcapPacket A captured packet
+type pcapPacket struct {
+	timestamp time.Time
+	linkType  uint32
+	data      []byte
+}
+
+// isPcap Check if the data starts with the magic of a pcap or pcapng file
+func isPcap(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	switch binary.BigEndian.Uint32(data) {
+	case 0xa1b2c3d4, 0xd4c3b2a1, 0xa1b23c4d, 0x4d3cb2a1, 0x0a0d0d0a:
+		return true
+	}
+	return false
+}
+
+// GetIOCsFromPcap Get the indicators from a packet capture (pcap or pcapng):
+// the queried domains and A, AAAA, and CNAME answers of DNS, the Host and URL of HTTP/1.x requests,
+// the SNI of TLS ClientHellos, and the remote IPs each packet was sent to or from.
+// Reserved IPs (private, loopback, etc) are skipped, and HTTP requests and ClientHellos must be in one segment.
+// Each IOC is only returned once, with the "timestamp", "flow" (ex: tcp 10.0.0.5:49152 -> 93.184.216.34:80),
+// and "protocol" (dns, http, tls, or ip) of the first packet it was in as Metadata.
+// If the capture is truncated, the IOCs from the packets before are returned with the error.
+func GetIOCsFromPcap(reader io.Reader) ([]*FoundIOC, error) {
+	buffered := bufio.NewReader(reader)
+	magic, err := buffered.Peek(4)
+	if err != nil || !isPcap(magic) {
+		return nil, fmt.Errorf("not a pcap or pcapng file")
+	}
+
+	found := &pcapIOCs{seen: map[string]bool{}}
+	if binary.BigEndian.Uint32(magic) == 0x0a0d0d0a {
+		err = readPcapng(buffered, found.packet)
+	} else {
+		err = readPcap(buffered, found.packet)
+	}
+	return found.iocs, err
+}
+
+// readPcap Read each packet of a pcap file
+func readPcap(reader io.Reader, handle func(pcapPacket)) error {
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return err
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if binary.BigEndian.Uint32(header) == 0xa1b2c3d4 || binary.BigEndian.Uint32(header) == 0xa1b23c4d {
+		order = binary.BigEndian
+	}
+	// The fraction of the timestamp is in nanoseconds instead of microseconds
+	nano := order.Uint32(header) == 0xa1b23c4d
+	linkType := order.Uint32(header[20:]) & 0xffff
+
+	record := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(reader, record); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("truncated capture: %s", err)
+		}
+		length := order.Uint32(record[8:])
+		if length > maxPacketSize {
+			return fmt.Errorf("packet of %d bytes is too large", length)
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return fmt.Errorf("truncated capture: %s", err)
+		}
+
+		fraction := time.Duration(order.Uint32(record[4:]))
+		if !nano {
+			fraction *= time.Microsecond
+		}
+		handle(pcapPacket{
+			timestamp: time.Unix(int64(order.Uint32(record)), int64(fraction)).UTC(),
+			linkType:  linkType,
+			data:      data,
+		})
+	}
+}
+
+// pcapngInterface The link type and timestamp resolution of an interface in a pcapng file
+type pcapngInterface struct {
+	linkType uint32
+	// units Number of timestamp units per second
+	units uint64
+}
+
+// readPcapng Read each packet of a pcapng file
+func readPcapng(reader io.Reader, handle func(pcapPacket)) error {
+	var order binary.ByteOrder = binary.LittleEndian
+	interfaces := []pcapngInterface{}
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(reader, header); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("truncated capture: %s", err)
+		}
+
+		blockType := order.Uint32(header)
+		read := uint32(len(header))
+		if blockType == 0x0a0d0d0a {
+			// Each section header has the byte order of the blocks after it, ex: for captures that were concatenated
+			magic := make([]byte, 4)
+			if _, err := io.ReadFull(reader, magic); err != nil {
+				return fmt.Errorf("truncated capture: %s", err)
+			}
+			order = binary.LittleEndian
+			if binary.BigEndian.Uint32(magic) == 0x1a2b3c4d {
+				order = binary.BigEndian
+			}
+			interfaces = interfaces[:0]
+			read += 4
+		}
+
+		length := order.Uint32(header[4:])
+		if length < read+4 || length%4 != 0 || length > maxPacketSize {
+			return fmt.Errorf("invalid pcapng block length %d", length)
+		}
+		block := make([]byte, length-read)
+		if _, err := io.ReadFull(reader, block); err != nil {
+			return fmt.Errorf("truncated capture: %s", err)
+		}
+		// Remove the trailing length
+		body := block[:len(block)-4]
+
+		switch blockType {
+		case 1: // Interface description
+			if len(body) < 8 {
+				return fmt.Errorf("invalid pcapng interface")
+			}
+			iface := pcapngInterface{linkType: uint32(order.Uint16(body)), units: 1e6}
+			pcapngOptions(body[8:], order, func(code uint16, value []byte) {
+				// if_tsresol is a power of 10, or of 2 if the high bit is set
+				if code == 9 && len(value) > 0 && value[0]&0x7f < 64 {
+					iface.units = 1
+					for i := byte(0); i < value[0]&0x7f; i++ {
+						if value[0]&0x80 != 0 {
+							iface.units *= 2
+						} else {
+							iface.units *= 10
+						}
+					}
+				}
+			})
+			interfaces = append(interfaces, iface)
+		case 6: // Enhanced packet
+			if len(body) < 20 {
+				return fmt.Errorf("invalid pcapng packet")
+			}
+			id, length := order.Uint32(body), order.Uint32(body[12:])
+			if int(id) >= len(interfaces) || int(length) > len(body)-20 {
+				return fmt.Errorf("invalid pcapng packet")
+			}
+			iface := interfaces[id]
+			units := uint64(order.Uint32(body[4:]))<<32 | uint64(order.Uint32(body[8:]))
+			handle(pcapPacket{
+				timestamp: time.Unix(int64(units/iface.units), int64(units%iface.units*1e9/iface.units)).UTC(),
+				linkType:  iface.linkType,
+				data:      body[20 : 20+length],
+			})
+		case 3: // Simple packet, which has no timestamp
+			if len(body) < 4 || len(interfaces) == 0 {
+				return fmt.Errorf("invalid pcapng packet")
+			}
+			length := order.Uint32(body)
+			if int(length) > len(body)-4 {
+				length = uint32(len(body) - 4)
+			}
+			handle(pcapPacket{linkType: interfaces[0].linkType, data: body[4 : 4+length]})
+		}
+	}
+}
+
+// pcapngOptions Call handle with each option of a block
+func pcapngOptions(options []byte, order binary.ByteOrder, handle func(code uint16, value []byte)) {
+	for len(options) >= 4 {
+		code, length := order.Uint16(options), int(order.Uint16(options[2:]))
+		if code == 0 || length > len(options)-4 {
+			return
+		}
+		handle(code, options[4:4+length])
+		// Values are padded to 32 bits
+		padded := 4 + (length+3)/4*4
+		if padded > len(options) {
+			return
+		}
+		options = options[padded:]
+	}
+}
+
+// pcapIOCs The IOCs found in the packets of a capture
+type pcapIOCs struct {
+	iocs []*FoundIOC
+	seen map[string]bool
+}
+
+// pcapFlow The addresses of a packet
+type pcapFlow struct {
+	protocol         string
+	src, dst         net.IP
+	srcPort, dstPort int
+}
+
+func (flow pcapFlow) String() string {
+	if flow.protocol != "tcp" && flow.protocol != "udp" {
+		return fmt.Sprintf("%s %s -> %s", flow.protocol, flow.src, flow.dst)
+	}
+	return fmt.Sprintf("%s %s -> %s", flow.protocol,
+		net.JoinHostPort(flow.src.String(), strconv.Itoa(flow.srcPort)), net.JoinHostPort(flow.dst.String(), strconv.Itoa(flow.dstPort)))
+}
+
+// add Add the IOC if it has not been found yet
+func (found *pcapIOCs) add(value string, t Type, packet pcapPacket, flow pcapFlow, protocol string) {
+	key := t.String() + " " + value
+	if found.seen[key] {
+		return
+	}
+	found.seen[key] = true
+
+	metadata := map[string]string{"flow": flow.String(), "protocol": protocol}
+	if !packet.timestamp.IsZero() {
+		metadata["timestamp"] = packet.timestamp.Format(time.RFC3339Nano)
+	}
+	found.iocs = append(found.iocs, &FoundIOC{IOC: value, Type: t, Metadata: metadata})
+}
+
+// addHost Add a host name or IP
+func (found *pcapIOCs) addHost(host string, packet pcapPacket, flow pcapFlow, protocol string) {
+	if ioc := hostIOC(host); ioc != nil {
+		found.add(ioc.IOC, ioc.Type, packet, flow, protocol)
+	}
+}
+
+// packet Get the IOCs from a packet
+func (found *pcapIOCs) packet(packet pcapPacket) {
+	flow, payload, ok := decodePacket(packet)
+	if !ok {
+		return
+	}
+
+	for _, ip := range []net.IP{flow.src, flow.dst} {
+		found.addHost(ip.String(), packet, flow, "ip")
+	}
+
+	switch {
+	case flow.protocol == "udp" && (flow.srcPort == 53 || flow.dstPort == 53):
+		found.dns(payload, packet, flow)
+	case flow.protocol == "tcp" && (flow.srcPort == 53 || flow.dstPort == 53):
+		// DNS over TCP has the length of the message first
+		if len(payload) > 2 {
+			found.dns(payload[2:], packet, flow)
+		}
+	case flow.protocol == "tcp":
+		if host, url := parseHTTPRequest(payload); host != "" {
+			found.addHost(host, packet, flow, "http")
+			if url != "" {
+				found.add(url, URL, packet, flow, "http")
+			}
+		} else if sni := parseClientHelloSNI(payload); sni != "" {
+			found.addHost(sni, packet, flow, "tls")
+		}
+	}
+}
+
+// decodePacket Get the addresses and transport payload of a packet
+func decodePacket(packet pcapPacket) (flow pcapFlow, payload []byte, ok bool) {
+	data := packet.data
+	switch packet.linkType {
+	case linkTypeEthernet:
+		if len(data) < 14 {
+			return flow, nil, false
+		}
+		etherType := binary.BigEndian.Uint16(data[12:])
+		data = data[14:]
+		// VLAN tags
+		for (etherType == 0x8100 || etherType == 0x88a8) && len(data) >= 4 {
+			etherType = binary.BigEndian.Uint16(data[2:])
+			data = data[4:]
+		}
+		if etherType != 0x0800 && etherType != 0x86dd {
+			return flow, nil, false
+		}
+	case linkTypeNull, linkTypeLoop:
+		if len(data) < 4 {
+			return flow, nil, false
+		}
+		data = data[4:]
+	case linkTypeLinuxSLL:
+		if len(data) < 16 {
+			return flow, nil, false
+		}
+		data = data[16:]
+	case linkTypeLinuxSLL2:
+		if len(data) < 20 {
+			return flow, nil, false
+		}
+		data = data[20:]
+	case linkTypeRaw, linkTypeIPv4, linkTypeIPv6:
+	default:
+		return flow, nil, false
+	}
+
+	var protocol byte
+	if len(data) >= 20 && data[0]>>4 == 4 {
+		headerLength := int(data[0]&0xf) * 4
+		totalLength := int(binary.BigEndian.Uint16(data[2:]))
+		if headerLength < 20 || headerLength > len(data) {
+			return flow, nil, false
+		}
+		if totalLength >= headerLength && totalLength < len(data) {
+			data = data[:totalLength]
+		}
+		flow.src, flow.dst = net.IP(data[12:16]), net.IP(data[16:20])
+		protocol = data[9]
+		payload = data[headerLength:]
+		// Later fragments do not have the transport header
+		if binary.BigEndian.Uint16(data[6:])&0x1fff != 0 {
+			payload = nil
+		}
+	} else if len(data) >= 40 && data[0]>>4 == 6 {
+		flow.src, flow.dst = net.IP(data[8:24]), net.IP(data[24:40])
+		protocol = data[6]
+		payload = data[40:]
+		// Skip the extension headers
+		for payload != nil {
+			switch protocol {
+			case 0, 43, 60: // Hop-by-hop, routing, and destination options
+				if len(payload) < 8 {
+					payload = nil
+					break
+				}
+				// The length is in 8 byte units, not counting the first 8 bytes
+				length := (int(payload[1]) + 1) * 8
+				if length > len(payload) {
+					payload = nil
+					break
+				}
+				protocol, payload = payload[0], payload[length:]
+				continue
+			case 44: // Fragment
+				if len(payload) < 8 || binary.BigEndian.Uint16(payload[2:])&0xfff8 != 0 {
+					payload = nil
+					break
+				}
+				protocol, payload = payload[0], payload[8:]
+				continue
+			}
+			break
+		}
+	} else {
+		return flow, nil, false
+	}
+
+	switch protocol {
+	case 6:
+		flow.protocol = "tcp"
+		if len(payload) < 20 || int(payload[12]>>4)*4 < 20 || int(payload[12]>>4)*4 > len(payload) {
+			return flow, nil, true
+		}
+		flow.srcPort, flow.dstPort = int(binary.BigEndian.Uint16(payload)), int(binary.BigEndian.Uint16(payload[2:]))
+		return flow, payload[int(payload[12]>>4)*4:], true
+	case 17:
+		flow.protocol = "udp"
+		if len(payload) < 8 {
+			return flow, nil, true
+		}
+		flow.srcPort, flow.dstPort = int(binary.BigEndian.Uint16(payload)), int(binary.BigEndian.Uint16(payload[2:]))
+		return flow, payload[8:], true
+	case 1:
+		flow.protocol = "icmp"
+	case 58:
+		flow.protocol = "icmpv6"
+	default:
+		flow.protocol = strconv.Itoa(int(protocol))
+	}
+	return flow, nil, true
+}
+
+// dns Add the queried names, and the names and IPs in the answers of a DNS message
+func (found *pcapIOCs) dns(message []byte, packet pcapPacket, flow pcapFlow) {
+	if len(message) < 12 {
+		return
+	}
+	questions, answers := int(binary.BigEndian.Uint16(message[4:])), int(binary.BigEndian.Uint16(message[6:]))
+	offset := 12
+	for i := 0; i < questions; i++ {
+		name, next, ok := dnsName(message, offset)
+		if !ok || next+4 > len(message) {
+			return
+		}
+		found.addHost(name, packet, flow, "dns")
+		offset = next + 4
+	}
+
+	for i := 0; i < answers; i++ {
+		_, next, ok := dnsName(message, offset)
+		if !ok || next+10 > len(message) {
+			return
+		}
+		recordType := binary.BigEndian.Uint16(message[next:])
+		length := int(binary.BigEndian.Uint16(message[next+8:]))
+		data := next + 10
+		if data+length > len(message) {
+			return
+		}
+
+		switch {
+		case recordType == 1 && length == 4, recordType == 28 && length == 16: // A and AAAA
+			found.addHost(net.IP(message[data:data+length]).String(), packet, flow, "dns")
+		case recordType == 5: // CNAME
+			if name, _, ok := dnsName(message, data); ok {
+				found.addHost(name, packet, flow, "dns")
+			}
+		}
+		offset = data + length
+	}
+}
+
+// dnsName Read a (possibly compressed) name from a DNS message, returning the offset after it
+func dnsName(message []byte, offset int) (string, int, bool) {
+	labels := []string{}
+	next := -1
+	for jumps := 0; ; {
+		if offset >= len(message) {
+			return "", 0, false
+		}
+		length := int(message[offset])
+		switch {
+		case length == 0:
+			if next == -1 {
+				next = offset + 1
+			}
+			return strings.Join(labels, "."), next, true
+		case length&0xc0 == 0xc0:
+			// Pointer to a name earlier in the message
+			if offset+1 >= len(message) || jumps > 32 {
+				return "", 0, false
+			}
+			if next == -1 {
+				next = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(message[offset:]) & 0x3fff)
+			jumps++
+		case length&0xc0 != 0 || offset+1+length > len(message):
+			return "", 0, false
+		default:
+			labels = append(labels, string(message[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+}
+
+// httpMethods The methods an HTTP/1.x request can start with
+var httpMethods = []string{"GET", "POST", "PUT", "HEAD", "DELETE", "OPTIONS", "PATCH", "CONNECT", "TRACE"}
+
+// parseHTTPRequest Get the host and URL of an HTTP/1.x request, both are empty if it is not a request
+func parseHTTPRequest(payload []byte) (host string, url string) {
+	end := strings.Index(string(payload[:minInt(len(payload), 8192)]), "\r\n\r\n")
+	if end == -1 {
+		end = minInt(len(payload), 8192)
+	}
+	lines := strings.Split(string(payload[:end]), "\r\n")
+	request := strings.Fields(lines[0])
+	if len(request) != 3 || !strings.HasPrefix(request[2], "HTTP/1.") {
+		return "", ""
+	}
+	isMethod := false
+	for _, method := range httpMethods {
+		isMethod = isMethod || request[0] == method
+	}
+	if !isMethod {
+		return "", ""
+	}
+
+	for _, line := range lines[1:] {
+		if colon := strings.Index(line, ":"); colon != -1 && strings.EqualFold(line[:colon], "Host") {
+			host = strings.TrimSpace(line[colon+1:])
+		}
+	}
+	target := request[1]
+	switch {
+	case request[0] == "CONNECT":
+		// The target is the host and port to tunnel to
+		host, url = target, ""
+	case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+		// Requests to proxies have the full URL
+		url = target
+		if host == "" {
+			host = strings.SplitN(strings.SplitN(target, "://", 2)[1], "/", 2)[0]
+		}
+	case host != "" && strings.HasPrefix(target, "/"):
+		url = "http://" + host + target
+	}
+
+	// Remove the port
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.Trim(host, "[]"), url
+}
+
+// parseClientHelloSNI Get the server name (SNI) of a TLS ClientHello, empty if it is not a ClientHello or has none
+func parseClientHelloSNI(payload []byte) string {
+	// Record header, then the handshake header
+	if len(payload) < 9 || payload[0] != 0x16 || payload[1] != 3 || payload[5] != 1 {
+		return ""
+	}
+	data := payload[9:]
+
+	// Version and random, then the session ID, cipher suites, and compression methods
+	if len(data) < 34 {
+		return ""
+	}
+	data = data[34:]
+	for _, lengthSize := range []int{1, 2, 1} {
+		if len(data) < lengthSize {
+			return ""
+		}
+		length := int(data[0])
+		if lengthSize == 2 {
+			length = int(binary.BigEndian.Uint16(data))
+		}
+		if len(data) < lengthSize+length {
+			return ""
+		}
+		data = data[lengthSize+length:]
+	}
+
+	if len(data) < 2 {
+		return ""
+	}
+	data = data[2:]
+	for len(data) >= 4 {
+		extension, length := binary.BigEndian.Uint16(data), int(binary.BigEndian.Uint16(data[2:]))
+		if len(data) < 4+length {
+			return ""
+		}
+		value := data[4 : 4+length]
+		data = data[4+length:]
+		if extension != 0 {
+			continue
+		}
+
+		// Server name list, each with a type (0 for host names) and length
+		if len(value) < 2 {
+			return ""
+		}
+		value = value[2:]
+		for len(value) >= 3 {
+			nameType, nameLength := value[0], int(binary.BigEndian.Uint16(value[1:]))
+			if len(value) < 3+nameLength {
+				return ""
+			}
+			if nameType == 0 {
+				return string(value[3 : 3+nameLength])
+			}
+			value = value[3+nameLength:]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,201 @@
+package ioc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Helpers to build packets, in network byte order
+
+func be16(v int) []byte {
+	return []byte{byte(v >> 8), byte(v)}
+}
+
+func ethernetIPv4(src, dst string, protocol byte, payload []byte) []byte {
+	packet := append(make([]byte, 12), 0x08, 0x00)
+	ip := []byte{0x45, 0}
+	ip = append(ip, be16(20+len(payload))...)
+	ip = append(ip, 0, 0, 0, 0, 64, protocol, 0, 0)
+	ip = append(ip, net.ParseIP(src).To4()...)
+	ip = append(ip, net.ParseIP(dst).To4()...)
+	return append(append(packet, ip...), payload...)
+}
+
+func udpSegment(srcPort, dstPort int, payload []byte) []byte {
+	segment := append(be16(srcPort), be16(dstPort)...)
+	segment = append(segment, be16(8+len(payload))...)
+	return append(append(segment, 0, 0), payload...)
+}
+
+func tcpSegment(srcPort, dstPort int, payload []byte) []byte {
+	segment := append(be16(srcPort), be16(dstPort)...)
+	segment = append(segment, 0, 0, 0, 1, 0, 0, 0, 0, 0x50, 0x18, 0xff, 0xff, 0, 0, 0, 0)
+	return append(segment, payload...)
+}
+
+func dnsLabels(name string) []byte {
+	ret := []byte{}
+	for _, label := range strings.Split(name, ".") {
+		ret = append(append(ret, byte(len(label))), label...)
+	}
+	return append(ret, 0)
+}
+
+// dnsResponse A response to an A query for the name with a CNAME and an A record, using compression
+func dnsResponse(name, cname string, ip string) []byte {
+	message := []byte{0x12, 0x34, 0x81, 0x80, 0, 1, 0, 2, 0, 0, 0, 0}
+	message = append(append(message, dnsLabels(name)...), 0, 1, 0, 1)
+	message = append(message, 0xc0, 12, 0, 5, 0, 1, 0, 0, 0, 60)
+	message = append(append(message, be16(len(dnsLabels(cname)))...), dnsLabels(cname)...)
+	message = append(message, 0xc0, byte(12+len(dnsLabels(name))+4+12), 0, 1, 0, 1, 0, 0, 0, 60, 0, 4)
+	return append(message, net.ParseIP(ip).To4()...)
+}
+
+func clientHello(sni string) []byte {
+	name := append([]byte{0}, be16(len(sni))...)
+	name = append(name, sni...)
+	extension := append([]byte{0, 0}, be16(len(name)+2)...)
+	extension = append(append(extension, be16(len(name))...), name...)
+
+	hello := append([]byte{3, 3}, make([]byte, 32)...)
+	hello = append(hello, 0, 0, 2, 0x13, 0x01, 1, 0)
+	hello = append(append(hello, be16(len(extension))...), extension...)
+	handshake := append([]byte{1, 0}, be16(len(hello))...)
+	handshake = append(handshake, hello...)
+	return append(append([]byte{0x16, 3, 1}, be16(len(handshake))...), handshake...)
+}
+
+var testPackets = [][]byte{
+	ethernetIPv4("10.0.0.5", "10.0.0.1", 17, udpSegment(50000, 53, dnsResponse("update.evil.com", "cdn.evil.net", "93.184.216.34"))),
+	ethernetIPv4("10.0.0.5", "93.184.216.34", 6, tcpSegment(49152, 80, []byte("GET /payload.bin HTTP/1.1\r\nHost: update.evil.com\r\nUser-Agent: x\r\n\r\n"))),
+	ethernetIPv4("10.0.0.5", "8.8.4.4", 6, tcpSegment(49153, 443, clientHello("c2.evil.org"))),
+	ethernetIPv4("93.184.216.34", "10.0.0.5", 6, tcpSegment(80, 49152, []byte("HTTP/1.1 200 OK\r\nServer: not.a.request.com\r\n\r\n"))),
+}
+
+var testPacketTime = time.Date(2020, 3, 3, 10, 22, 33, 500000000, time.UTC)
+
+func buildPcap(packets [][]byte) []byte {
+	ret := new(bytes.Buffer)
+	binary.Write(ret, binary.LittleEndian, []uint32{0xa1b2c3d4, 0x00040002, 0, 0, 65535, 1})
+	for _, packet := range packets {
+		binary.Write(ret, binary.LittleEndian, []uint32{uint32(testPacketTime.Unix()), 500000, uint32(len(packet)), uint32(len(packet))})
+		ret.Write(packet)
+	}
+	return ret.Bytes()
+}
+
+func buildPcapng(packets [][]byte) []byte {
+	ret := new(bytes.Buffer)
+	block := func(blockType uint32, body []byte) {
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+		binary.Write(ret, binary.BigEndian, []uint32{blockType, uint32(len(body) + 12)})
+		ret.Write(body)
+		binary.Write(ret, binary.BigEndian, uint32(len(body)+12))
+	}
+	block(0x0a0d0d0a, []byte{0x1a, 0x2b, 0x3c, 0x4d, 0, 1, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	// Ethernet, with nanosecond timestamps
+	block(1, []byte{0, 1, 0, 0, 0, 0, 0xff, 0xff, 0, 9, 0, 1, 9, 0, 0, 0, 0, 0, 0, 0})
+	for _, packet := range packets {
+		units := uint64(testPacketTime.UnixNano())
+		body := new(bytes.Buffer)
+		binary.Write(body, binary.BigEndian, []uint32{0, uint32(units >> 32), uint32(units), uint32(len(packet)), uint32(len(packet))})
+		body.Write(packet)
+		block(6, body.Bytes())
+	}
+	return ret.Bytes()
+}
+
+func TestGetIOCsFromPcap(t *testing.T) {
+	metadata := func(flow, protocol string) map[string]string {
+		return map[string]string{"timestamp": "2020-03-03T10:22:33.5Z", "flow": flow, "protocol": protocol}
+	}
+	dnsFlow, httpFlow, tlsFlow := "udp 10.0.0.5:50000 -> 10.0.0.1:53", "tcp 10.0.0.5:49152 -> 93.184.216.34:80", "tcp 10.0.0.5:49153 -> 8.8.4.4:443"
+	want := []*FoundIOC{
+		{IOC: "update.evil.com", Type: Domain, Metadata: metadata(dnsFlow, "dns")},
+		{IOC: "cdn.evil.net", Type: Domain, Metadata: metadata(dnsFlow, "dns")},
+		{IOC: "93.184.216.34", Type: IPv4, Metadata: metadata(dnsFlow, "dns")},
+		{IOC: "http://update.evil.com/payload.bin", Type: URL, Metadata: metadata(httpFlow, "http")},
+		{IOC: "8.8.4.4", Type: IPv4, Metadata: metadata(tlsFlow, "ip")},
+		{IOC: "c2.evil.org", Type: Domain, Metadata: metadata(tlsFlow, "tls")},
+	}
+
+	for name, data := range map[string][]byte{"pcap": buildPcap(testPackets), "pcapng": buildPcapng(testPackets)} {
+		if got := DetectContentType(data); got != ContentPcap {
+			t.Errorf("%s: detected as %s", name, got)
+		}
+		got, err := GetIOCsFromPcap(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, wanted %v", name, got, want)
+			for _, ioc := range got {
+				t.Log(ioc.IOC, ioc.Type, ioc.Metadata)
+			}
+		}
+
+		// Truncated captures return the IOCs from the complete packets
+		got, err = GetIOCsFromPcap(bytes.NewReader(data[:len(data)-10]))
+		if err == nil {
+			t.Errorf("%s: should have errored on a truncated capture", name)
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s: got %d IOCs from a truncated capture, wanted %d", name, len(got), len(want))
+		}
+	}
+
+	if _, err := GetIOCsFromPcap(strings.NewReader("not a capture")); err == nil {
+		t.Errorf("Should have errored on something that is not a capture")
+	}
+}
+
+func TestParseHTTPRequest(t *testing.T) {
+	tests := []struct {
+		payload   string
+		host, url string
+	}{
+		{"GET /a?b=c HTTP/1.1\r\nHost: evil.com:8080\r\n\r\n", "evil.com", "http://evil.com:8080/a?b=c"},
+		{"GET http://evil.com/a HTTP/1.1\r\nAccept: */*\r\n\r\n", "evil.com", "http://evil.com/a"},
+		{"CONNECT evil.com:443 HTTP/1.1\r\nHost: evil.com:443\r\n\r\n", "evil.com", ""},
+		{"POST /upload HTTP/1.0\r\nhost: [2606:4700::1]\r\n\r\n", "2606:4700::1", "http://[2606:4700::1]/upload"},
+		{"HTTP/1.1 200 OK\r\nHost: evil.com\r\n\r\n", "", ""},
+		{"\x16\x03\x01", "", ""},
+	}
+	for i, test := range tests {
+		if host, url := parseHTTPRequest([]byte(test.payload)); host != test.host || url != test.url {
+			t.Errorf("Test %d: got %q %q, wanted %q %q", i, host, url, test.host, test.url)
+		}
+	}
+}
+
+func TestDecodePacketIPv6ExtensionHeaders(t *testing.T) {
+	ipv6 := func(nextHeader byte, payload []byte) []byte {
+		ip := append([]byte{0x60, 0, 0, 0}, be16(len(payload))...)
+		ip = append(ip, nextHeader, 64)
+		ip = append(ip, net.ParseIP("2001:db8::1")...)
+		ip = append(ip, net.ParseIP("2001:db8::2")...)
+		return append(ip, payload...)
+	}
+
+	// Hop-by-hop options, then UDP
+	hopByHop := append([]byte{17, 0, 0, 0, 0, 0, 0, 0}, udpSegment(50000, 53, []byte("x"))...)
+	flow, payload, ok := decodePacket(pcapPacket{linkType: linkTypeRaw, data: ipv6(0, hopByHop)})
+	if !ok || flow.protocol != "udp" || flow.dstPort != 53 || string(payload) != "x" {
+		t.Errorf("got %v %q %v, wanted the UDP payload", flow, payload, ok)
+	}
+
+	// A length of 255 (2048 bytes) that is longer than the packet, which used to overflow and never advance
+	hopByHop = []byte{0, 255, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	flow, payload, ok = decodePacket(pcapPacket{linkType: linkTypeRaw, data: ipv6(0, hopByHop)})
+	if !ok || payload != nil || flow.protocol != "0" {
+		t.Errorf("got %v %q %v, wanted no payload", flow, payload, ok)
+	}
+}
@@ -0,0 +1,757 @@
+package ioc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	// maxPDFPageDepth Deepest the page tree and form XObjects are followed
+	maxPDFPageDepth = 32
+	// maxPDFCMapRange Most codes a single bfrange of a ToUnicode CMap can map
+	maxPDFCMapRange = 1 << 16
+)
+
+// GetIOCsFromPDF Get the IOCs from the text of a PDF.
+// Lines are joined back together where hyphenation or wrapping split a word, hash, or URL.
+// If the streams decompress to more than the default MaxSize, the IOCs found are returned with the error.
+func GetIOCsFromPDF(reader io.ReaderAt, size int64) ([]*IOC, error) {
+	iocs, err := getIOCsFromPDF(reader, size, newContentExtractor(ContentOptions{}))
+	return ToIOCs(iocs), err
+}
+
+func getIOCsFromPDF(reader io.ReaderAt, size int64, e *contentExtractor) ([]*FoundIOC, error) {
+	data := make([]byte, size)
+	if n, err := reader.ReadAt(data, 0); err != nil && !(err == io.EOF && int64(n) == size) {
+		return nil, err
+	}
+
+	text, err := pdfText(data, e)
+	if text == "" && err != nil {
+		return nil, err
+	}
+	text = joinWrappedLines(text)
+	return e.reveal(FoundIOCs(GetIOCs(text, e.options.GetFangedIOCs), ""), text), err
+}
+
+// pdfDocument All the objects of a PDF
+type pdfDocument struct {
+	objects  map[int]interface{}
+	trailers []pdfDict
+	// extractor Decompresses the streams, and decoded has the streams already decoded, so each is only decompressed once
+	extractor *contentExtractor
+	decoded   map[*pdfStream][]byte
+	// err Set if the streams decompressed to more than the size limit
+	err error
+}
+
+var pdfObjectStart = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// parsePDF Find every object in the PDF by scanning for them rather than trusting the xref table,
+// which is often broken in the PDFs we are given
+func parsePDF(data []byte, e *contentExtractor) (*pdfDocument, error) {
+	if !bytes.Contains(data[:minInt(len(data), 1024)], []byte("%PDF-")) {
+		return nil, fmt.Errorf("not a PDF")
+	}
+
+	doc := &pdfDocument{objects: map[int]interface{}{}, extractor: e, decoded: map[*pdfStream][]byte{}}
+	end := 0
+	for _, match := range pdfObjectStart.FindAllSubmatchIndex(data, -1) {
+		if match[0] < end {
+			// Inside a stream or object we already parsed
+			continue
+		}
+		var num int
+		fmt.Sscan(string(data[match[2]:match[3]]), &num)
+
+		l := &pdfLexer{data: data, pos: match[1]}
+		object, err := l.object()
+		if err != nil {
+			continue
+		}
+		if dict, ok := object.(pdfDict); ok {
+			if stream := readPDFStream(l, dict); stream != nil {
+				object = stream
+			}
+		}
+		doc.objects[num] = object
+		end = l.pos
+	}
+
+	// Trailers have the root of the document, or it is in the xref streams
+	for i := 0; i+7 <= len(data); {
+		location := bytes.Index(data[i:], []byte("trailer"))
+		if location == -1 {
+			break
+		}
+		l := &pdfLexer{data: data, pos: i + location + 7}
+		if dict, err := l.object(); err == nil {
+			if dict, ok := dict.(pdfDict); ok {
+				doc.trailers = append(doc.trailers, dict)
+			}
+		}
+		i += location + 7
+	}
+	for _, object := range doc.objects {
+		if stream, ok := object.(*pdfStream); ok && stream.dict["Type"] == pdfName("XRef") {
+			doc.trailers = append(doc.trailers, stream.dict)
+		}
+	}
+	for _, trailer := range doc.trailers {
+		if trailer["Encrypt"] != nil {
+			return nil, fmt.Errorf("encrypted PDFs are not supported")
+		}
+	}
+
+	doc.expandObjectStreams()
+	return doc, nil
+}
+
+// readPDFStream Read the stream after a dictionary, if there is one
+func readPDFStream(l *pdfLexer, dict pdfDict) *pdfStream {
+	l.skipSpace()
+	if !bytes.HasPrefix(l.data[l.pos:], []byte("stream")) {
+		return nil
+	}
+	start := l.pos + len("stream")
+	if bytes.HasPrefix(l.data[start:], []byte("\r\n")) {
+		start += 2
+	} else if start < len(l.data) && (l.data[start] == '\n' || l.data[start] == '\r') {
+		start++
+	}
+
+	// Trust the length only if it lands on the end of the stream
+	if length, ok := dict["Length"].(float64); ok && length >= 0 && start+int(length) <= len(l.data) {
+		end := start + int(length)
+		if bytes.HasPrefix(bytes.TrimLeft(l.data[end:], "\r\n "), []byte("endstream")) {
+			l.pos = end
+			return &pdfStream{dict, l.data[start:end]}
+		}
+	}
+
+	end := bytes.Index(l.data[start:], []byte("endstream"))
+	if end == -1 {
+		l.pos = len(l.data)
+		return &pdfStream{dict, l.data[start:]}
+	}
+	l.pos = start + end
+	return &pdfStream{dict, bytes.TrimRight(l.data[start:start+end], "\r\n")}
+}
+
+// expandObjectStreams Add the objects that are compressed in object streams
+func (doc *pdfDocument) expandObjectStreams() {
+	for _, object := range doc.objects {
+		stream, ok := object.(*pdfStream)
+		if !ok || stream.dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		data, err := doc.decode(stream)
+		if err != nil {
+			continue
+		}
+		n, _ := stream.dict["N"].(float64)
+		first, _ := stream.dict["First"].(float64)
+		if int(first) > len(data) {
+			continue
+		}
+
+		// Header of pairs of object number and offset
+		l := &pdfLexer{data: data}
+		for i := 0; i < int(n); i++ {
+			num, err1 := l.token()
+			offset, err2 := l.token()
+			num1, ok1 := num.(float64)
+			offset1, ok2 := offset.(float64)
+			if err1 != nil || err2 != nil || !ok1 || !ok2 {
+				break
+			}
+			if _, exists := doc.objects[int(num1)]; exists || int(first+offset1) >= len(data) {
+				continue
+			}
+			if object, err := (&pdfLexer{data: data, pos: int(first + offset1)}).object(); err == nil {
+				doc.objects[int(num1)] = object
+			}
+		}
+	}
+}
+
+// decode Get the data of a stream (see decodeStream), remembering when the size limit is hit
+func (doc *pdfDocument) decode(stream *pdfStream) ([]byte, error) {
+	if data, ok := doc.decoded[stream]; ok {
+		return data, nil
+	}
+	data, err := decodeStream(stream, doc.extractor)
+	if err != nil {
+		if doc.err == nil && doc.extractor.decompressed > doc.extractor.options.MaxSize {
+			doc.err = err
+		}
+		return nil, err
+	}
+	doc.decoded[stream] = data
+	return data, nil
+}
+
+// resolve Follow references to get the object
+func (doc *pdfDocument) resolve(object interface{}) interface{} {
+	for i := 0; i < maxPDFPageDepth; i++ {
+		ref, ok := object.(pdfRef)
+		if !ok {
+			return object
+		}
+		object = doc.objects[ref.num]
+	}
+	return nil
+}
+
+// dict Resolve the object as a dictionary, using the dictionary of a stream
+func (doc *pdfDocument) dict(object interface{}) pdfDict {
+	switch object := doc.resolve(object).(type) {
+	case pdfDict:
+		return object
+	case *pdfStream:
+		return object.dict
+	}
+	return nil
+}
+
+// pdfPage A page and the resources it inherited
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages Get the pages in order from the page tree, or every page object if there is no tree
+func (doc *pdfDocument) pages() []pdfPage {
+	pages := []pdfPage{}
+	visited := map[interface{}]bool{}
+	var walk func(node interface{}, resources pdfDict, depth int)
+	walk = func(node interface{}, resources pdfDict, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref] {
+				return
+			}
+			visited[ref] = true
+		}
+		dict := doc.dict(node)
+		if dict == nil || depth > maxPDFPageDepth {
+			return
+		}
+		if r := doc.dict(dict["Resources"]); r != nil {
+			resources = r
+		}
+		if kids, ok := doc.resolve(dict["Kids"]).(pdfArray); ok {
+			for _, kid := range kids {
+				walk(kid, resources, depth+1)
+			}
+			return
+		}
+		if dict["Type"] == pdfName("Page") || dict["Contents"] != nil {
+			pages = append(pages, pdfPage{dict, resources})
+		}
+	}
+
+	for _, trailer := range doc.trailers {
+		if root := doc.dict(trailer["Root"]); root != nil {
+			walk(root["Pages"], nil, 0)
+		}
+		if len(pages) > 0 {
+			return pages
+		}
+	}
+
+	// No usable page tree
+	nums := []int{}
+	for num := range doc.objects {
+		if dict := doc.dict(doc.objects[num]); dict != nil && dict["Type"] == pdfName("Page") {
+			nums = append(nums, num)
+		}
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		dict := doc.dict(doc.objects[num])
+		pages = append(pages, pdfPage{dict, doc.dict(dict["Resources"])})
+	}
+	return pages
+}
+
+// pdfText Get the text of all the pages of the PDF, with a newline between lines of text.
+// If the streams decompress to more than the size limit, the text that was found is returned with the error.
+func pdfText(data []byte, e *contentExtractor) (string, error) {
+	doc, err := parsePDF(data, e)
+	if err != nil {
+		return "", err
+	}
+
+	text := &pdfTextWriter{}
+	for _, page := range doc.pages() {
+		contents := []byte{}
+		streams, ok := doc.resolve(page.dict["Contents"]).(pdfArray)
+		if !ok {
+			streams = pdfArray{page.dict["Contents"]}
+		}
+		for _, stream := range streams {
+			if stream, ok := doc.resolve(stream).(*pdfStream); ok {
+				if data, err := doc.decode(stream); err == nil {
+					contents = append(append(contents, data...), '\n')
+				}
+			}
+		}
+		doc.showContent(contents, page.resources, text, 0)
+		text.newline()
+	}
+	return text.String(), doc.err
+}
+
+// pdfFont How to get unicode text out of the strings shown with a font
+type pdfFont struct {
+	toUnicode   map[string]string
+	codeLengths []int // Byte lengths of the codes in the strings
+}
+
+func (doc *pdfDocument) font(object interface{}) *pdfFont {
+	dict := doc.dict(object)
+	font := &pdfFont{codeLengths: []int{1}}
+	if dict == nil {
+		return font
+	}
+	if dict["Subtype"] == pdfName("Type0") {
+		font.codeLengths = []int{2}
+	}
+	if stream, ok := doc.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		if data, err := doc.decode(stream); err == nil {
+			parseToUnicode(data, font)
+		}
+	}
+	return font
+}
+
+// parseToUnicode Parse the code space and mappings of a ToUnicode CMap
+func parseToUnicode(data []byte, font *pdfFont) {
+	font.toUnicode = map[string]string{}
+	lengths := map[int]bool{}
+
+	l := &pdfLexer{data: data}
+	operands := []interface{}{}
+	section := ""
+	for l.pos < len(l.data) {
+		tok, err := l.token()
+		if err != nil {
+			continue
+		}
+		keyword, ok := tok.(pdfKeyword)
+		if !ok {
+			operands = append(operands, tok)
+			continue
+		}
+		if keyword == "[" {
+			// Arrays of destinations in a bfrange
+			l.pos--
+			if array, err := l.object(); err == nil {
+				operands = append(operands, array)
+			}
+			continue
+		}
+
+		switch string(keyword) {
+		case "begincodespacerange", "beginbfchar", "beginbfrange":
+			section = string(keyword)
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				if low, ok := operands[i].(pdfString); ok {
+					lengths[len(low)] = true
+				}
+			}
+			section = ""
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					font.toUnicode[string(src)] = utf16BEString(dst)
+					lengths[len(src)] = true
+				}
+			}
+			section = ""
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				low, ok1 := operands[i].(pdfString)
+				high, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 || len(low) != len(high) || len(low) == 0 {
+					continue
+				}
+				lengths[len(low)] = true
+				start, end := bytesToInt(low), bytesToInt(high)
+				for code := start; code <= end && code-start < maxPDFCMapRange; code++ {
+					src := intToBytes(code, len(low))
+					switch dst := operands[i+2].(type) {
+					case pdfString:
+						font.toUnicode[string(src)] = utf16BEString(incrementUTF16BE(dst, code-start))
+					case pdfArray:
+						if code-start < len(dst) {
+							if dst, ok := dst[code-start].(pdfString); ok {
+								font.toUnicode[string(src)] = utf16BEString(dst)
+							}
+						}
+					}
+				}
+			}
+			section = ""
+		}
+		if section == "" || strings.HasPrefix(string(keyword), "begin") {
+			operands = operands[:0]
+		}
+	}
+
+	if len(lengths) > 0 {
+		font.codeLengths = []int{}
+		for length := range lengths {
+			font.codeLengths = append(font.codeLengths, length)
+		}
+		sort.Ints(font.codeLengths)
+	}
+}
+
+// decode Get the unicode text of a string shown with this font
+func (font *pdfFont) decode(s []byte) string {
+	if font.toUnicode == nil {
+		if font.codeLengths[0] == 2 {
+			// Without a ToUnicode map, the codes of composite fonts are usually meaningless glyph ids
+			return ""
+		}
+		runes := make([]rune, len(s))
+		for i, b := range s {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+
+	ret := ""
+	for len(s) > 0 {
+		matched := false
+		for _, length := range font.codeLengths {
+			if length <= len(s) {
+				if text, ok := font.toUnicode[string(s[:length])]; ok {
+					ret += text
+					s = s[length:]
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			s = s[minInt(font.codeLengths[0], len(s)):]
+		}
+	}
+	return ret
+}
+
+// pdfTextWriter Builds the text of a PDF, adding spaces and newlines based on where the text is drawn
+type pdfTextWriter struct {
+	bytes.Buffer
+	drawn      bool
+	lastX      float64 // Where the last text ended
+	lastY      float64
+	lastHeight float64
+}
+
+func (w *pdfTextWriter) newline() {
+	if w.Len() > 0 && !bytes.HasSuffix(w.Bytes(), []byte("\n")) {
+		w.WriteByte('\n')
+	}
+	w.drawn = false
+}
+
+// write Write text drawn at x, y, with a height of size
+func (w *pdfTextWriter) write(text string, x, y, size, width float64) {
+	if text == "" {
+		return
+	}
+	if w.drawn {
+		height := math.Max(math.Max(size, w.lastHeight), 1)
+		switch {
+		case math.Abs(y-w.lastY) > height/2:
+			w.newline()
+		case x-w.lastX > height/4 || x < w.lastX-height:
+			if !bytes.HasSuffix(w.Bytes(), []byte(" ")) && !strings.HasPrefix(text, " ") {
+				w.WriteByte(' ')
+			}
+		}
+	}
+	w.WriteString(text)
+	w.drawn = true
+	w.lastX, w.lastY, w.lastHeight = x+width, y, size
+}
+
+// pdfMatrix a b c d e f
+type pdfMatrix [6]float64
+
+var pdfIdentity = pdfMatrix{1, 0, 0, 1, 0, 0}
+
+func (m pdfMatrix) multiply(n pdfMatrix) pdfMatrix {
+	return pdfMatrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// showContent Interpret a content stream, writing the text it shows.
+// Glyph widths are estimated as half the font size as we do not read font metrics.
+func (doc *pdfDocument) showContent(content []byte, resources pdfDict, w *pdfTextWriter, depth int) {
+	if depth > maxPDFPageDepth {
+		return
+	}
+	fonts := doc.dict(resources["Font"])
+	xObjects := doc.dict(resources["XObject"])
+	fontCache := map[pdfName]*pdfFont{}
+
+	font := &pdfFont{codeLengths: []int{1}}
+	fontSize, leading, charSpace, wordSpace, scale := 0.0, 0.0, 0.0, 0.0, 1.0
+	tm, lm := pdfIdentity, pdfIdentity
+
+	number := func(v interface{}) float64 {
+		f, _ := v.(float64)
+		return f
+	}
+	moveLine := func(tx, ty float64) {
+		lm = pdfMatrix{1, 0, 0, 1, tx, ty}.multiply(lm)
+		tm = lm
+	}
+	show := func(s []byte) {
+		text := font.decode(s)
+		size := fontSize * math.Hypot(tm[2], tm[3])
+		width := 0.0
+		for _, r := range text {
+			advance := fontSize/2 + charSpace
+			if r == ' ' {
+				advance += wordSpace
+			}
+			width += advance * scale
+		}
+		w.write(text, tm[4], tm[5], size, width*tm[0])
+		tm = pdfMatrix{1, 0, 0, 1, width, 0}.multiply(tm)
+	}
+
+	l := &pdfLexer{data: content}
+	operands := []interface{}{}
+	for l.pos < len(l.data) {
+		object, err := l.object()
+		if err != nil {
+			operands = operands[:0]
+			continue
+		}
+		op, ok := object.(pdfKeyword)
+		if !ok {
+			operands = append(operands, object)
+			continue
+		}
+
+		arg := func(i int) interface{} {
+			if i < len(operands) {
+				return operands[i]
+			}
+			return nil
+		}
+		last := func() interface{} {
+			return arg(len(operands) - 1)
+		}
+
+		switch op {
+		case "BT":
+			tm, lm = pdfIdentity, pdfIdentity
+		case "Tf":
+			if name, ok := arg(0).(pdfName); ok {
+				if fontCache[name] == nil {
+					fontCache[name] = doc.font(fonts[name])
+				}
+				font = fontCache[name]
+			}
+			fontSize = number(arg(1))
+		case "TL":
+			leading = number(arg(0))
+		case "Tc":
+			charSpace = number(arg(0))
+		case "Tw":
+			wordSpace = number(arg(0))
+		case "Tz":
+			scale = number(arg(0)) / 100
+		case "Td":
+			moveLine(number(arg(0)), number(arg(1)))
+		case "TD":
+			leading = -number(arg(1))
+			moveLine(number(arg(0)), number(arg(1)))
+		case "Tm":
+			if len(operands) >= 6 {
+				for i := range lm {
+					lm[i] = number(operands[i])
+				}
+				tm = lm
+			}
+		case "T*":
+			moveLine(0, -leading)
+		case "Tj":
+			if s, ok := last().(pdfString); ok {
+				show(s)
+			}
+		case "'", "\"":
+			if op == "\"" && len(operands) >= 3 {
+				wordSpace, charSpace = number(arg(0)), number(arg(1))
+			}
+			moveLine(0, -leading)
+			if s, ok := last().(pdfString); ok {
+				show(s)
+			}
+		case "TJ":
+			array, _ := last().(pdfArray)
+			for _, item := range array {
+				switch item := item.(type) {
+				case pdfString:
+					show(item)
+				case float64:
+					// Large negative adjustments are spaces between words
+					tx := -item / 1000 * fontSize * scale
+					if item < -200 {
+						w.write(" ", tm[4], tm[5], fontSize*math.Hypot(tm[2], tm[3]), tx*tm[0])
+					}
+					tm = pdfMatrix{1, 0, 0, 1, tx, 0}.multiply(tm)
+				}
+			}
+		case "Do":
+			name, _ := arg(0).(pdfName)
+			if form, ok := doc.resolve(xObjects[name]).(*pdfStream); ok && form.dict["Subtype"] == pdfName("Form") {
+				formResources := doc.dict(form.dict["Resources"])
+				if formResources == nil {
+					formResources = resources
+				}
+				if data, err := doc.decode(form); err == nil {
+					doc.showContent(data, formResources, w, depth+1)
+				}
+			}
+		case "BI":
+			// Skip inline image data
+			if end := pdfInlineImageEnd.FindIndex(l.data[l.pos:]); end != nil {
+				l.pos += end[1]
+			} else {
+				l.pos = len(l.data)
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+var pdfInlineImageEnd = regexp.MustCompile(`\sEI\b`)
+
+var (
+	hexToken = regexp.MustCompile(`^[A-Fa-f0-9]+$`)
+	urlToken = regexp.MustCompile(`(?i)^(\w+[[(]?://|www[.[(])`)
+)
+
+// joinWrappedLines Join lines back together where a word, hash, or URL was split between them.
+// Hyphens are removed from hyphenated words, but kept where they are part of an IOC.
+func joinWrappedLines(text string) string {
+	lines := strings.Split(text, "\n")
+	ret := []string{}
+	for _, line := range lines {
+		if len(ret) == 0 {
+			ret = append(ret, line)
+			continue
+		}
+		previous := ret[len(ret)-1]
+		if joined, ok := joinLines(previous, line); ok {
+			ret[len(ret)-1] = joined
+			continue
+		}
+		ret = append(ret, line)
+	}
+	return strings.Join(ret, "\n")
+}
+
+// joinLines Join two lines if the first ends with a token that continues on the second
+func joinLines(previous, next string) (string, bool) {
+	previousFields, nextFields := strings.Fields(previous), strings.Fields(next)
+	if len(previousFields) == 0 || len(nextFields) == 0 || strings.TrimRightFunc(previous, unicode.IsSpace) != previous {
+		return "", false
+	}
+	end, start := previousFields[len(previousFields)-1], nextFields[0]
+	next = strings.TrimLeftFunc(next, unicode.IsSpace)
+	first, _ := utf8.DecodeRuneInString(start)
+
+	switch {
+	case strings.HasSuffix(end, "-") && len(end) > 1 && (unicode.IsLetter(first) || unicode.IsDigit(first)):
+		// Hyphenated words lose their hyphen, but hyphens in IOCs (domains, CVEs) are kept
+		word := strings.TrimSuffix(end, "-")
+		if strings.IndexFunc(word, func(r rune) bool { return !unicode.IsLetter(r) }) == -1 && unicode.IsLower(first) && !strings.ContainsAny(start, ".[(/@") {
+			return strings.TrimSuffix(previous, "-") + next, true
+		}
+		return previous + next, true
+	case hexToken.MatchString(end) && hexToken.MatchString(start) && !isHashLength(len(end)) && len(end)+len(start) <= 128:
+		// Hashes split across lines, either the two halves make a full hash, or it continues on more lines
+		if isHashLength(len(end)+len(start)) || len(nextFields) == 1 {
+			return previous + next, true
+		}
+	case urlToken.MatchString(end) && !unicode.IsUpper(first):
+		// URLs continue if they were split at a separator or the next line looks like part of a URL
+		if strings.ContainsAny(end[len(end)-1:], "/-_?=&%#[") || strings.ContainsAny(start, "/?=&%[") || strings.Contains(strings.TrimRight(start, ".,;:"), ".") {
+			return previous + next, true
+		}
+	}
+	return "", false
+}
+
+func isHashLength(length int) bool {
+	return length == 32 || length == 40 || length == 64 || length == 128
+}
+
+func utf16BEString(b []byte) string {
+	codes := make([]uint16, len(b)/2)
+	for i := range codes {
+		codes[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return string(utf16.Decode(codes))
+}
+
+// incrementUTF16BE Add to the last code unit of a UTF-16BE string, used for bfrange destinations
+func incrementUTF16BE(b []byte, n int) []byte {
+	ret := append([]byte{}, b...)
+	if len(ret) >= 2 {
+		last := int(ret[len(ret)-2])<<8 | int(ret[len(ret)-1])
+		last += n
+		ret[len(ret)-2], ret[len(ret)-1] = byte(last>>8), byte(last)
+	}
+	return ret
+}
+
+func bytesToInt(b []byte) int {
+	ret := 0
+	for _, c := range b {
+		ret = ret<<8 | int(c)
+	}
+	return ret
+}
+
+func intToBytes(n, length int) []byte {
+	ret := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		ret[i] = byte(n)
+		n >>= 8
+	}
+	return ret
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
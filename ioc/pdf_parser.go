@@ -0,0 +1,322 @@
+package ioc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// Minimal PDF object parser, just enough to get to the text of the pages
+
+type pdfName string
+type pdfKeyword string
+type pdfString []byte
+type pdfArray []interface{}
+type pdfDict map[pdfName]interface{}
+type pdfRef struct {
+	num, gen int
+}
+type pdfStream struct {
+	dict pdfDict
+	data []byte // Still encoded
+}
+
+// maxPDFNesting Deepest arrays and dictionaries can be nested
+const maxPDFNesting = 64
+
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(b byte) bool {
+	return b == ' ' || b == '\n' || b == '\r' || b == '\t' || b == '\f' || b == 0
+}
+
+func isPDFDelimiter(b byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), b) != -1
+}
+
+// skipSpace Skip whitespace and comments
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		switch {
+		case isPDFSpace(l.data[l.pos]):
+			l.pos++
+		case l.data[l.pos] == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// token Read the next primitive token.  Delimiters of arrays and dictionaries are returned as keywords.
+func (l *pdfLexer) token() (interface{}, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, fmt.Errorf("unexpected end of data")
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '(':
+		return l.literalString()
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return pdfKeyword("<<"), nil
+	case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+		l.pos += 2
+		return pdfKeyword(">>"), nil
+	case c == '<':
+		return l.hexString()
+	case c == '/':
+		l.pos++
+		return pdfName(l.name()), nil
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		l.pos++
+		return pdfKeyword([]byte{c}), nil
+	case c == ')' || c == '>':
+		l.pos++
+		return nil, fmt.Errorf("unexpected %q", c)
+	}
+
+	word := l.regular()
+	if number, err := strconv.ParseFloat(word, 64); err == nil {
+		return number, nil
+	}
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	return pdfKeyword(word), nil
+}
+
+// regular Read a run of regular characters
+func (l *pdfLexer) regular() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// name Read a name (after the /), decoding #xx escapes
+func (l *pdfLexer) name() string {
+	name := l.regular()
+	for i := 0; i+2 < len(name); i++ {
+		if name[i] != '#' {
+			continue
+		}
+		if b, err := hex.DecodeString(name[i+1 : i+3]); err == nil {
+			name = name[:i] + string(b) + name[i+3:]
+		}
+	}
+	return name
+}
+
+func (l *pdfLexer) literalString() (pdfString, error) {
+	l.pos++ // (
+	ret := []byte{}
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return ret, nil
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				break
+			}
+			c = l.data[l.pos]
+			l.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				// Line continuation
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			}
+			if c >= '0' && c <= '7' {
+				// Octal character code, up to 3 digits
+				value := int(c - '0')
+				for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+					value = value*8 + int(l.data[l.pos]-'0')
+					l.pos++
+				}
+				c = byte(value)
+			}
+		}
+		ret = append(ret, c)
+	}
+	return nil, fmt.Errorf("unterminated string")
+}
+
+func (l *pdfLexer) hexString() (pdfString, error) {
+	l.pos++ // <
+	end := bytes.IndexByte(l.data[l.pos:], '>')
+	if end == -1 {
+		l.pos = len(l.data)
+		return nil, fmt.Errorf("unterminated hex string")
+	}
+	digits := []byte{}
+	for _, c := range l.data[l.pos : l.pos+end] {
+		if isHexDigit(c) {
+			digits = append(digits, c)
+		}
+	}
+	l.pos += end + 1
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	ret := make([]byte, len(digits)/2)
+	hex.Decode(ret, digits)
+	return ret, nil
+}
+
+// object Read a full object, including arrays, dictionaries, and references
+func (l *pdfLexer) object() (interface{}, error) {
+	return l.nestedObject(0)
+}
+
+func (l *pdfLexer) nestedObject(depth int) (interface{}, error) {
+	if depth > maxPDFNesting {
+		return nil, fmt.Errorf("objects nested too deeply")
+	}
+
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case pdfKeyword:
+		switch tok {
+		case "[":
+			array := pdfArray{}
+			for {
+				l.skipSpace()
+				if l.pos < len(l.data) && l.data[l.pos] == ']' {
+					l.pos++
+					return array, nil
+				}
+				value, err := l.nestedObject(depth + 1)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			}
+		case "<<":
+			dict := pdfDict{}
+			for {
+				key, err := l.token()
+				if err != nil {
+					return nil, err
+				}
+				if key == pdfKeyword(">>") {
+					return dict, nil
+				}
+				name, ok := key.(pdfName)
+				if !ok {
+					return nil, fmt.Errorf("dictionary key %v is not a name", key)
+				}
+				value, err := l.nestedObject(depth + 1)
+				if err != nil {
+					return nil, err
+				}
+				dict[name] = value
+			}
+		}
+	case float64:
+		// Check for a reference: num gen R
+		start := l.pos
+		if gen, err := l.token(); err == nil {
+			if gen, ok := gen.(float64); ok {
+				if r, err := l.token(); err == nil && r == pdfKeyword("R") {
+					return pdfRef{int(tok), int(gen)}, nil
+				}
+			}
+		}
+		l.pos = start
+	}
+	return tok, nil
+}
+
+// decodeStream Apply the filters of a stream to get its data, decompressing through the extractor so its size limit applies
+func decodeStream(stream *pdfStream, e *contentExtractor) ([]byte, error) {
+	filters := pdfArray{}
+	switch filter := stream.dict["Filter"].(type) {
+	case pdfName:
+		filters = append(filters, filter)
+	case pdfArray:
+		filters = filter
+	}
+
+	data := stream.data
+	for _, filter := range filters {
+		switch filter {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			reader, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			// Keep what we can of truncated or corrupt streams
+			decoded, err := e.read(reader)
+			if err != nil && len(decoded) == 0 {
+				return nil, err
+			}
+			data = decoded
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			end := bytes.IndexByte(data, '>')
+			if end == -1 {
+				end = len(data)
+			}
+			decoded, err := (&pdfLexer{data: append(append([]byte{'<'}, data[:end]...), '>')}).hexString()
+			if err != nil {
+				return nil, err
+			}
+			data = decoded
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			if end := bytes.Index(data, []byte("~>")); end != -1 {
+				data = data[:end]
+			}
+			decoded := make([]byte, len(data))
+			n, _, err := ascii85.Decode(decoded, bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~")), true)
+			if err != nil {
+				return nil, err
+			}
+			data = decoded[:n]
+		default:
+			return nil, fmt.Errorf("unsupported filter %v", filter)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,220 @@
+package ioc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// buildPDF Build a PDF with the objects (numbered from 1) and an xref table.  Empty objects are left out.
+func buildPDF(objects []string) []byte {
+	ret := bytes.NewBufferString("%PDF-1.5\n%\xe2\xe3\xcf\xd3\n")
+	offsets := []int{}
+	for i, object := range objects {
+		if object == "" {
+			offsets = append(offsets, -1)
+			continue
+		}
+		offsets = append(offsets, ret.Len())
+		fmt.Fprintf(ret, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := ret.Len()
+	fmt.Fprintf(ret, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		if offset == -1 {
+			fmt.Fprintf(ret, "0000000000 00000 f \n")
+			continue
+		}
+		fmt.Fprintf(ret, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(ret, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return ret.Bytes()
+}
+
+func pdfStreamObject(dict string, data []byte, compress bool) string {
+	if compress {
+		compressed := new(bytes.Buffer)
+		w := zlib.NewWriter(compressed)
+		w.Write(data)
+		w.Close()
+		data = compressed.Bytes()
+		dict += " /Filter /FlateDecode"
+	}
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+var testPDFContent = `BT
+/F1 10 Tf
+72 700 Td
+(The sample 0242ebb681eb1b3dbaa751320dea56e31c5e) Tj
+0 -14 Td
+(52c8324a7de125a8144cc5270698 beacons to hxxps://185[.]159[.]82[.]15/holly) Tj
+0 -14 Td
+(hole/c644[.]php after mal-) Tj
+0 -14 Td
+(ware installation \(see CVE-2020-) Tj
+0 -14 Td
+(0601\).) Tj
+0 -14 Td
+[(evil) -10 (\133.\135com) -500 (was) -250 (also) -300 (used)] TJ
+ET
+BT
+/F2 10 Tf
+1 0 0 1 72 600 Tm
+<000500160009000C0020000F00120007> Tj
+ET
+`
+
+var testPDFCMap = `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+1 beginbfchar
+<0020> <005B002E005D>
+endbfchar
+1 beginbfrange
+<0001> <001A> <0061>
+endbfrange
+endcmap
+CMapName currentdict /CMap defineresource pop
+end
+end`
+
+func testPDF() []byte {
+	// The page tree and a font are compressed in an object stream
+	pages := "<< /Type /Pages /Kids [3 0 R] /Count 1 /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> >>"
+	font := "<< /Type /Font /Subtype /Type0 /BaseFont /Custom /Encoding /Identity-H /ToUnicode 7 0 R >>"
+	header := fmt.Sprintf("2 0 5 %d ", len(pages)+1)
+
+	return buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 6 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"",
+		pdfStreamObject("", []byte(testPDFContent), true),
+		pdfStreamObject("", []byte(testPDFCMap), false),
+		pdfStreamObject(fmt.Sprintf("/Type /ObjStm /N 2 /First %d", len(header)), []byte(header+pages+"\n"+font), true),
+	})
+}
+
+func TestPDFText(t *testing.T) {
+	got, err := pdfText(testPDF(), newContentExtractor(ContentOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `The sample 0242ebb681eb1b3dbaa751320dea56e31c5e
+52c8324a7de125a8144cc5270698 beacons to hxxps://185[.]159[.]82[.]15/holly
+hole/c644[.]php after mal-
+ware installation (see CVE-2020-
+0601).
+evil[.]com was also used
+evil[.]org
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, want)
+	}
+}
+
+func TestGetIOCsFromPDF(t *testing.T) {
+	data := testPDF()
+	got, err := GetIOCsFromPDF(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*IOC{
+		{IOC: "0242ebb681eb1b3dbaa751320dea56e31c5e52c8324a7de125a8144cc5270698", Type: SHA256},
+		{IOC: "hxxps://185[.]159[.]82[.]15/hollyhole/c644[.]php", Type: URL},
+		{IOC: "CVE-2020-0601", Type: CVE},
+		{IOC: "evil[.]com", Type: Domain},
+		{IOC: "evil[.]org", Type: Domain},
+	}
+outer:
+	for _, want := range want {
+		for _, ioc := range got {
+			if reflect.DeepEqual(ioc, want) {
+				continue outer
+			}
+		}
+		t.Errorf("Did not find %v in %v", want, got)
+	}
+	for _, ioc := range got {
+		if ioc.Type == MD5 {
+			t.Errorf("Found part of a split hash: %v", ioc)
+		}
+	}
+
+	if _, err := GetIOCsFromPDF(strings.NewReader("not a pdf"), 9); err == nil {
+		t.Errorf("Should have errored on something that is not a PDF")
+	}
+	encrypted := bytes.Replace(data, []byte("/Size"), []byte("/Encrypt 9 0 R /Size"), 1)
+	if _, err := GetIOCsFromPDF(bytes.NewReader(encrypted), int64(len(encrypted))); err == nil {
+		t.Errorf("Should have errored on an encrypted PDF")
+	}
+}
+
+func TestJoinWrappedLines(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// Hyphenation
+		{"the mal-\nware was", "the malware was"},
+		{"visit my-\nsite[.]com", "visit my-site[.]com"},
+		{"CVE-2020-\n0601", "CVE-2020-0601"},
+		{"the well-\nKnown", "the well-Known"},
+		// Hashes
+		{"874058e8d8582bf8\n5c115ce319c5b0af text", "874058e8d8582bf85c115ce319c5b0af text"},
+		{"874058e8d8582bf85c115ce319c5b0af\n874058e8d8582bf85c115ce319c5b0af", "874058e8d8582bf85c115ce319c5b0af\n874058e8d8582bf85c115ce319c5b0af"},
+		{"0242ebb681eb1b3d\nbaa751320dea56e31c5e\n52c8324a7de125a8144cc5270698", "0242ebb681eb1b3dbaa751320dea56e31c5e52c8324a7de125a8144cc5270698"},
+		{"add\nbeef cafe", "add\nbeef cafe"},
+		// URLs
+		{"hxxp://example[.]com/a/\nb.php", "hxxp://example[.]com/a/b.php"},
+		{"hxxp://example[.]com/path?a=\n1&b=2", "hxxp://example[.]com/path?a=1&b=2"},
+		{"see https://example.com\nThe next", "see https://example.com\nThe next"},
+		{"see https://example.com\nand more", "see https://example.com\nand more"},
+		// Nothing to join
+		{"line one\nline two", "line one\nline two"},
+		{"trailing space \nline", "trailing space \nline"},
+	}
+	for _, test := range tests {
+		if got := joinWrappedLines(test.input); got != test.want {
+			t.Errorf("joinWrappedLines(%q) = %q, wanted %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestGetIOCsFromPDFLimits(t *testing.T) {
+	// A content stream that decompresses to much more than the limit
+	content := "BT /F1 10 Tf 72 700 Td (hxxp://evil[.]com) Tj ET\n" + strings.Repeat(" ", 100000)
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		pdfStreamObject("", []byte(content), true),
+	})
+	if _, err := GetIOCsFromContentWithOptions(data, ContentOptions{MaxSize: 10000}); err == nil {
+		t.Errorf("Should have errored on a stream over the size limit")
+	}
+	iocs, err := GetIOCsFromContentWithOptions(data, ContentOptions{})
+	if err != nil || !containsFoundIOC(iocs, &IOC{IOC: "hxxp://evil[.]com", Type: URL}) {
+		t.Errorf("got %v %v, wanted hxxp://evil[.]com", iocs, err)
+	}
+
+	// Decode and Deobfuscate apply to the text
+	data = buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		pdfStreamObject("", []byte("BT /F1 10 Tf 72 700 Td (aHR0cDovL2V2aWwuY29tL3BheWxvYWQ=) Tj ET"), true),
+	})
+	iocs, err = GetIOCsFromContentWithOptions(data, ContentOptions{Decode: true})
+	if err != nil || !containsFoundIOC(iocs, &IOC{IOC: "http://evil.com/payload", Type: URL}) {
+		t.Errorf("got %v %v, wanted the decoded URL", iocs, err)
+	}
+}
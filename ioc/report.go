@@ -0,0 +1,230 @@
+package ioc
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// ReportOptions Options used when generating markdown and HTML reports
+type ReportOptions struct {
+	Title  string    // Defaults to "IOC Report"
+	Source string    // Where the IOCs came from, ex: the article URL
+	Time   time.Time // Extraction time, defaults to now
+	Fanged bool      // Print IOCs fanged instead of defanged
+	// Text The text the IOCs were extracted from.  If set, a snippet of the text around each IOC is included.
+	Text string
+	// ContextLength Characters of context on each side of the IOC, defaults to 40
+	ContextLength int
+}
+
+// reportSection All IOCs of a type in a report
+type reportSection struct {
+	Type  Type
+	Count int
+	IOCs  []reportIOC
+}
+
+type reportIOC struct {
+	Value   string
+	Context string
+}
+
+// PrintIOCsMarkdown Takes []IOC and returns a markdown report with a section for each type
+func PrintIOCsMarkdown(iocs []*IOC, options ReportOptions) string {
+	options = reportDefaults(options)
+	sections := reportSections(iocs, options)
+
+	ret := fmt.Sprintf("# %s\n\n", markdownEscape(options.Title))
+	if options.Source != "" {
+		ret += fmt.Sprintf("**Source:** %s  \n", markdownEscape(options.Source))
+	}
+	ret += fmt.Sprintf("**Extracted:** %s\n", options.Time.UTC().Format("2006-01-02 15:04:05 UTC"))
+
+	if len(sections) == 0 {
+		return ret + "\nNo IOCs found.\n"
+	}
+
+	ret += "\n| Type | Count |\n| --- | ---: |\n"
+	for _, section := range sections {
+		ret += fmt.Sprintf("| %s | %d |\n", section.Type, section.Count)
+	}
+
+	for _, section := range sections {
+		ret += fmt.Sprintf("\n## %s (%d)\n\n", section.Type, section.Count)
+		for _, ioc := range section.IOCs {
+			ret += "- " + markdownCode(ioc.Value)
+			if ioc.Context != "" {
+				ret += " - " + markdownCode(ioc.Context)
+			}
+			ret += "\n"
+		}
+	}
+
+	return ret
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #eee; }
+code { font-family: monospace; word-break: break-all; }
+.context { color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if .Source}}
+<p><strong>Source:</strong> {{.Source}}</p>
+{{- end}}
+<p><strong>Extracted:</strong> {{.Time}}</p>
+{{- if not .Sections}}
+<p>No IOCs found.</p>
+{{- else}}
+<table>
+<tr><th>Type</th><th>Count</th></tr>
+{{- range .Sections}}
+<tr><td><a href="#{{.Type}}">{{.Type}}</a></td><td>{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{- range .Sections}}
+<h2 id="{{.Type}}">{{.Type}} ({{.Count}})</h2>
+<table>
+{{- range .IOCs}}
+<tr><td><code>{{.Value}}</code></td>{{if $.Context}}<td class="context"><code>{{.Context}}</code></td>{{end}}</tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+</body>
+</html>`))
+
+// PrintIOCsHTML Takes []IOC and returns a self contained HTML report with a section for each type
+func PrintIOCsHTML(iocs []*IOC, options ReportOptions) string {
+	options = reportDefaults(options)
+
+	ret := new(bytes.Buffer)
+	// Executing our own template with known data can not fail
+	htmlReportTemplate.Execute(ret, struct {
+		Title    string
+		Source   string
+		Time     string
+		Context  bool
+		Sections []reportSection
+	}{
+		options.Title,
+		options.Source,
+		options.Time.UTC().Format("2006-01-02 15:04:05 UTC"),
+		options.Text != "",
+		reportSections(iocs, options),
+	})
+	return ret.String()
+}
+
+// reportSections Group the IOCs by type, in the order of Types
+func reportSections(iocs []*IOC, options ReportOptions) []reportSection {
+	counts := GetIOCsCounts(iocs)
+
+	sections := []reportSection{}
+	for _, t := range Types {
+		if counts[t] == 0 {
+			continue
+		}
+		section := reportSection{Type: t, Count: counts[t]}
+		for _, ioc := range iocs {
+			if ioc.Type != t {
+				continue
+			}
+			value := ioc.Fang()
+			if !options.Fanged {
+				value = value.Defang()
+			}
+			section.IOCs = append(section.IOCs, reportIOC{value.IOC, reportContext(ioc, options)})
+		}
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// reportContext Get the text surrounding the first occurrence of the IOC
+func reportContext(ioc *IOC, options ReportOptions) string {
+	if options.Text == "" {
+		return ""
+	}
+
+	// The IOC may have been standardized since it was found, so try each form
+	location := -1
+	value := ""
+	for _, value = range []string{ioc.IOC, ioc.Fang().IOC, ioc.Fang().Defang().IOC} {
+		if location = strings.Index(options.Text, value); location != -1 {
+			break
+		}
+	}
+	if location == -1 {
+		return ""
+	}
+
+	start := location - options.ContextLength
+	prefix := "..."
+	if start <= 0 {
+		start = 0
+		prefix = ""
+	}
+	end := location + len(value) + options.ContextLength
+	suffix := "..."
+	if end >= len(options.Text) {
+		end = len(options.Text)
+		suffix = ""
+	}
+	// Don't split multi byte characters
+	for start > 0 && !isRuneStart(options.Text[start]) {
+		start--
+	}
+	for end < len(options.Text) && !isRuneStart(options.Text[end]) {
+		end++
+	}
+
+	return prefix + strings.Join(strings.Fields(options.Text[start:end]), " ") + suffix
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// markdownCode Wrap a value in a code span, using a longer fence if the value has backticks
+func markdownCode(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// markdownEscape Escape characters with a special meaning in markdown
+func markdownEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`, "|", `\|`).Replace(s)
+}
+
+func reportDefaults(options ReportOptions) ReportOptions {
+	if options.Title == "" {
+		options.Title = "IOC Report"
+	}
+	if options.Time.IsZero() {
+		options.Time = time.Now()
+	}
+	if options.ContextLength == 0 {
+		options.ContextLength = 40
+	}
+	return options
+}
@@ -0,0 +1,84 @@
+package ioc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var reportTestIOCs = []*IOC{
+	{"hxxp[://]example[.]com/a", URL},
+	{"example.com", Domain},
+	{"1.2.3.4", IPv4},
+	{"bad.com", Domain},
+}
+
+func TestPrintIOCsMarkdown(t *testing.T) {
+	got := PrintIOCsMarkdown(reportTestIOCs, ReportOptions{
+		Source: "https://example.com/report_1",
+		Time:   time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC),
+		Text:   "The actor used example.com and later\nmoved to 1.2.3.4 for exfil",
+
+		ContextLength: 20,
+	})
+	want := "# IOC Report\n" +
+		"\n" +
+		"**Source:** https://example.com/report\\_1  \n" +
+		"**Extracted:** 2020-03-01 12:00:00 UTC\n" +
+		"\n" +
+		"| Type | Count |\n" +
+		"| --- | ---: |\n" +
+		"| Domain | 2 |\n" +
+		"| IPv4 | 1 |\n" +
+		"| URL | 1 |\n" +
+		"\n" +
+		"## Domain (2)\n" +
+		"\n" +
+		"- `example[.]com` - `The actor used example.com and later moved to...`\n" +
+		"- `bad[.]com`\n" +
+		"\n" +
+		"## IPv4 (1)\n" +
+		"\n" +
+		"- `1[.]2[.]3[.]4` - `...and later moved to 1.2.3.4 for exfil`\n" +
+		"\n" +
+		"## URL (1)\n" +
+		"\n" +
+		"- `hxxp[://]example[.]com/a`\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, want)
+	}
+
+	// Fanged and empty
+	got = PrintIOCsMarkdown([]*IOC{{"example[.]com", Domain}}, ReportOptions{Fanged: true})
+	if !strings.Contains(got, "- `example.com`\n") {
+		t.Errorf("Expected fanged IOC in:\n%s", got)
+	}
+	if got = PrintIOCsMarkdown(nil, ReportOptions{}); !strings.HasSuffix(got, "No IOCs found.\n") {
+		t.Errorf("Expected no IOCs message in:\n%s", got)
+	}
+
+	// Markdown in the title and values is escaped
+	got = PrintIOCsMarkdown([]*IOC{{"*evil*_`x`|y.exe", File}}, ReportOptions{Title: "APT_1 *new* | `c2`"})
+	for _, want := range []string{"# APT\\_1 \\*new\\* \\| \\`c2\\`\n", "- ``*evil*_`x`|y.exe``\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Missing %s in:\n%s", want, got)
+		}
+	}
+}
+
+func TestPrintIOCsHTML(t *testing.T) {
+	got := PrintIOCsHTML(append(reportTestIOCs, &IOC{"<script>.com", Domain}), ReportOptions{Title: "Bad & Report", Text: "used example.com"})
+
+	for _, want := range []string{
+		"<title>Bad &amp; Report</title>",
+		`<tr><td><a href="#Domain">Domain</a></td><td>3</td></tr>`,
+		`<h2 id="Domain">Domain (3)</h2>`,
+		`<tr><td><code>example[.]com</code></td><td class="context"><code>used example.com</code></td></tr>`,
+		`<tr><td><code>&lt;script&gt;[.]com</code></td><td class="context"><code></code></td></tr>`,
+		`<h2 id="URL">URL (1)</h2>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Missing %s in:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package ioc
+
+import (
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// rewriteTypes The types that can be fanged and defanged, in the order they are preferred when they start at the same place
+var rewriteTypes = []Type{URL, Email, IPv6, IPv4, Domain}
+
+// defangedIPv6 A defanged IPv6, which the IPv6 regex does not match, ex: fe80[:][:]1
+var defangedIPv6 = regexp.MustCompile(`\b(?:[a-f0-9]{1,4}(?:\[:\]|:)|\[:\]){2,7}[a-f0-9]{0,4}\b`)
+
+// DefangText Defang every IOC in the text (see Defang), leaving everything else, including IOCs that are already defanged, as is.
+// Ex: "Go to http://evil.com now" -> "Go to hxxp[://]evil[.]com now"
+func DefangText(text string) string {
+	return rewriteIOCs(text, func(ioc *IOC) string {
+		if !ioc.IsFanged() {
+			return ioc.IOC
+		}
+		return ioc.Defang().IOC
+	})
+}
+
+// FangText Fang every defanged IOC in the text (see Fang), leaving everything else as is.
+// Ex: "Go to hxxp[://]evil[.]com now" -> "Go to http://evil.com now"
+func FangText(text string) string {
+	return rewriteIOCs(text, func(ioc *IOC) string {
+		if ioc.IsFanged() {
+			return ioc.IOC
+		}
+		return ioc.Fang().IOC
+	})
+}
+
+// DefangHTML Defang the IOCs in the text and links (href) of an HTML document, see DefangText.
+// The rest of the document, including its scripts and styles, is left byte for byte as is.
+func DefangHTML(document string) string {
+	return rewriteHTML(document, DefangText)
+}
+
+// FangHTML Fang the IOCs in the text and links (href) of an HTML document, see FangText
+func FangHTML(document string) string {
+	return rewriteHTML(document, FangText)
+}
+
+// rewriteSpan Where an IOC is in the text
+type rewriteSpan struct {
+	start, end int
+	iocType    Type
+}
+
+// rewriteIOCs Replace each IOC in the text with the result of rewrite.
+// IOCs that overlap are joined in to the one that starts first (ex: the domain in a URL, or the defanged domain of john[AT]evil[.]com).
+func rewriteIOCs(text string, rewrite func(*IOC) string) string {
+	spans := []rewriteSpan{}
+	add := func(iocType Type, locations [][]int) {
+		for _, location := range locations {
+			// The end of a sentence is not part of an email
+			for iocType == Email && location[1] > location[0] && text[location[1]-1] == '.' {
+				location[1]--
+			}
+			// The IPv6 regex matches times like 10:30:00
+			if iocType == IPv6 && net.ParseIP((&IOC{IOC: text[location[0]:location[1]], Type: IPv6}).Fang().IOC) == nil {
+				continue
+			}
+			spans = append(spans, rewriteSpan{location[0], location[1], iocType})
+		}
+	}
+	for _, iocType := range rewriteTypes {
+		add(iocType, iocRegexes[iocType].FindAllStringIndex(text, -1))
+	}
+	add(IPv6, defangedIPv6.FindAllStringIndex(text, -1))
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+
+	ret := new(strings.Builder)
+	last := 0
+	for i := 0; i < len(spans); i++ {
+		span := spans[i]
+		for i+1 < len(spans) && spans[i+1].start < span.end {
+			if spans[i+1].end > span.end {
+				span.end = spans[i+1].end
+			}
+			i++
+		}
+		ret.WriteString(text[last:span.start])
+		ret.WriteString(rewrite(&IOC{IOC: text[span.start:span.end], Type: span.iocType}))
+		last = span.end
+	}
+	ret.WriteString(text[last:])
+	return ret.String()
+}
+
+// htmlHref The href attribute of a tag and its (maybe quoted) value
+var htmlHref = regexp.MustCompile(`(?i)(\shref\s*=\s*)("[^"]*"|'[^']*'|[^\s"'>]+)`)
+
+// rewriteHTML Rewrite the text nodes and hrefs of an HTML document, keeping the raw bytes of everything else
+func rewriteHTML(document string, rewrite func(string) string) string {
+	ret := new(strings.Builder)
+	tokenizer := html.NewTokenizer(strings.NewReader(document))
+	rawText := false
+	for {
+		tokenType := tokenizer.Next()
+		raw := string(tokenizer.Raw())
+		switch tokenType {
+		case html.ErrorToken:
+			// The end of the input, the tokenizer reads from a string so it can not fail otherwise
+			ret.WriteString(raw)
+			return ret.String()
+		case html.TextToken:
+			if !rawText {
+				raw = rewrite(raw)
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			rawText = tokenType == html.StartTagToken && (string(name) == "script" || string(name) == "style")
+			raw = htmlHref.ReplaceAllStringFunc(raw, func(attribute string) string {
+				groups := htmlHref.FindStringSubmatch(attribute)
+				value := groups[2]
+				if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'") {
+					return groups[1] + value[:1] + rewrite(value[1:len(value)-1]) + value[:1]
+				}
+				return groups[1] + rewrite(value)
+			})
+		case html.EndTagToken:
+			rawText = false
+		}
+		ret.WriteString(raw)
+	}
+}
@@ -0,0 +1,55 @@
+package ioc
+
+import "testing"
+
+func TestDefangText(t *testing.T) {
+	tests := []struct {
+		text     string
+		defanged string
+		fanged   string
+	}{
+		{
+			"See http://evil.com/a?b=1, bad.net and 1.2.3.4.\n\tMail john@evil.org.",
+			"See hxxp[://]evil[.]com/a?b=1, bad[.]net and 1[.]2[.]3[.]4.\n\tMail john[AT]evil[.]org.",
+			"See http://evil.com/a?b=1, bad.net and 1.2.3.4.\n\tMail john@evil.org.",
+		},
+		{
+			"Already hxxp://old(.)com and fe80::1 at 10:30:00",
+			"Already hxxp://old(.)com and fe80[:][:]1 at 10:30:00",
+			"Already http://old.com and fe80::1 at 10:30:00",
+		},
+		{
+			"No indicators, MD5 874058e8d8582bf85c115ce319c5b0af",
+			"No indicators, MD5 874058e8d8582bf85c115ce319c5b0af",
+			"No indicators, MD5 874058e8d8582bf85c115ce319c5b0af",
+		},
+	}
+	for _, test := range tests {
+		defanged := DefangText(test.text)
+		if defanged != test.defanged {
+			t.Errorf("DefangText(%q) = %q, wanted %q", test.text, defanged, test.defanged)
+		}
+		if fanged := FangText(defanged); fanged != test.fanged {
+			t.Errorf("FangText(%q) = %q, wanted %q", defanged, fanged, test.fanged)
+		}
+	}
+}
+
+func TestDefangHTML(t *testing.T) {
+	document := `<!DOCTYPE html>
+<html><head><title>evil.com</title><script>var u = "http://x.com";</script><style>a { background: url(http://y.com/a.png) }</style></head>
+<body><a HREF='http://evil.com/x' class=link>see http://evil.com/x</a><img src="http://i.com/a.png"/>
+<p>bad.net&amp;more <a href=http://c2.org>link</a></p></body></html>`
+	want := `<!DOCTYPE html>
+<html><head><title>evil[.]com</title><script>var u = "http://x.com";</script><style>a { background: url(http://y.com/a.png) }</style></head>
+<body><a HREF='hxxp[://]evil[.]com/x' class=link>see hxxp[://]evil[.]com/x</a><img src="http://i.com/a.png"/>
+<p>bad[.]net&amp;more <a href=hxxp[://]c2[.]org>link</a></p></body></html>`
+
+	defanged := DefangHTML(document)
+	if defanged != want {
+		t.Errorf("got %s, wanted %s", defanged, want)
+	}
+	if fanged := FangHTML(defanged); fanged != document {
+		t.Errorf("Should have fanged back to the original, got %s", fanged)
+	}
+}
@@ -0,0 +1,183 @@
+package ioc
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// RuleOptions Options used when generating Suricata or Snort rules
+type RuleOptions struct {
+	SIDStart  int    // First sid to use, defaults to 1000000 (the start of the local rule range)
+	SIDEnd    int    // Last sid that can be used, 0 for no limit
+	Classtype string // Defaults to trojan-activity
+	// Message Template for the msg of each rule.  {type} and {ioc} are replaced with the IOC's type and defanged value
+	Message   string
+	Reference string // URL of the article the IOCs came from
+}
+
+const (
+	defaultSIDStart     = 1000000
+	defaultRuleClass    = "trojan-activity"
+	defaultRuleMessage  = "go-ioc {type} {ioc}"
+	ruleDialectSuricata = "suricata"
+	ruleDialectSnort    = "snort"
+)
+
+// PrintIOCsSuricata Takes []IOC and returns Suricata rules alerting on them.
+// Domains alert on dns.query and tls.sni, URLs on http.host and http.uri, and IPs on any ip traffic.
+// Types that can not be detected on the network are skipped.
+func PrintIOCsSuricata(iocs []*IOC, options RuleOptions) (string, error) {
+	return generateRules(iocs, options, ruleDialectSuricata)
+}
+
+// PrintIOCsSnort Takes []IOC and returns Snort 2.9 rules alerting on them.
+// Domains alert on DNS queries and TLS client hellos, URLs on the http host header and uri, and IPs on any ip traffic.
+// Types that can not be detected on the network are skipped.
+func PrintIOCsSnort(iocs []*IOC, options RuleOptions) (string, error) {
+	return generateRules(iocs, options, ruleDialectSnort)
+}
+
+// generateRules Generate the rules for each IOC in the dialect
+func generateRules(iocs []*IOC, options RuleOptions, dialect string) (string, error) {
+	if options.SIDStart == 0 {
+		options.SIDStart = defaultSIDStart
+	}
+	if options.Classtype == "" {
+		options.Classtype = defaultRuleClass
+	}
+	if options.Message == "" {
+		options.Message = defaultRuleMessage
+	}
+
+	// One set of rules for each fanged IOC, so the different defangs of an IOC do not get duplicate rules
+	fangedIOCs := []*IOC{}
+	values := fangedValuesByType(iocs)
+	for _, t := range Types {
+		for _, value := range values[t] {
+			fangedIOCs = append(fangedIOCs, &IOC{IOC: value, Type: t})
+		}
+	}
+
+	rules := []string{}
+	sid := options.SIDStart
+	for _, ioc := range fangedIOCs {
+		var bodies []ruleBody
+		if dialect == ruleDialectSuricata {
+			bodies = suricataRuleBodies(ioc)
+		} else {
+			bodies = snortRuleBodies(ioc)
+		}
+
+		for _, body := range bodies {
+			if options.SIDEnd != 0 && sid > options.SIDEnd {
+				return "", fmt.Errorf("sid range %d-%d is too small for the rules", options.SIDStart, options.SIDEnd)
+			}
+
+			msg := strings.NewReplacer("{type}", ioc.Type.String(), "{ioc}", ioc.Defang().IOC).Replace(options.Message)
+			rule := fmt.Sprintf(`%s (msg:"%s";`, body.header, ruleEscapeMsg(msg))
+			if body.options != "" {
+				rule += " " + body.options
+			}
+			if options.Reference != "" {
+				rule += fmt.Sprintf(" reference:url,%s;", ruleReferenceURL(options.Reference))
+			}
+			rule += fmt.Sprintf(" classtype:%s; sid:%d; rev:1;)", options.Classtype, sid)
+
+			rules = append(rules, rule)
+			sid++
+		}
+	}
+
+	return strings.Join(rules, "\n"), nil
+}
+
+// ruleBody The header and detection options of a rule, without the msg and metadata
+type ruleBody struct {
+	header  string
+	options string
+}
+
+// suricataRuleBodies Get the suricata rules for this fanged IOC
+func suricataRuleBodies(ioc *IOC) []ruleBody {
+	switch ioc.Type {
+	case Domain:
+		content := fmt.Sprintf(`content:"%s"; nocase; bsize:%d;`, ruleEscapeContent(ioc.IOC), len(ioc.IOC))
+		return []ruleBody{
+			{"alert dns $HOME_NET any -> any any", "dns.query; " + content},
+			{"alert tls $HOME_NET any -> $EXTERNAL_NET any", "flow:established,to_server; tls.sni; " + content},
+		}
+	case URL:
+		u, err := url.Parse(ioc.IOC)
+		if err != nil || u.Hostname() == "" {
+			return nil
+		}
+		return []ruleBody{{"alert http $HOME_NET any -> $EXTERNAL_NET any",
+			fmt.Sprintf(`flow:established,to_server; http.host; content:"%s"; nocase; bsize:%d; http.uri; content:"%s"; startswith;`,
+				ruleEscapeContent(u.Hostname()), len(u.Hostname()), ruleEscapeContent(u.RequestURI()))}}
+	case IPv4, IPv6:
+		return []ruleBody{{fmt.Sprintf("alert ip $HOME_NET any -> %s any", ioc.IOC), ""}}
+	}
+	return nil
+}
+
+// snortRuleBodies Get the snort rules for this fanged IOC
+func snortRuleBodies(ioc *IOC) []ruleBody {
+	switch ioc.Type {
+	case Domain:
+		return []ruleBody{
+			{"alert udp $HOME_NET any -> any 53",
+				fmt.Sprintf(`content:"|01 00 00 01 00 00 00 00 00 00|"; depth:10; offset:2; content:"%s"; nocase; distance:0; fast_pattern;`, ruleDNSName(ioc.IOC))},
+			{"alert tcp $HOME_NET any -> $EXTERNAL_NET 443",
+				fmt.Sprintf(`flow:established,to_server; content:"|16 03|"; depth:2; content:"%s"; nocase; distance:0; fast_pattern;`, ruleEscapeContent(ioc.IOC))},
+		}
+	case URL:
+		u, err := url.Parse(ioc.IOC)
+		if err != nil || u.Hostname() == "" {
+			return nil
+		}
+		return []ruleBody{{"alert tcp $HOME_NET any -> $EXTERNAL_NET $HTTP_PORTS",
+			fmt.Sprintf(`flow:established,to_server; content:"Host|3A 20|%s"; http_header; nocase; content:"%s"; http_uri; depth:%d;`,
+				ruleEscapeContent(u.Hostname()), ruleEscapeContent(u.RequestURI()), len(u.RequestURI()))}}
+	case IPv4, IPv6:
+		return []ruleBody{{fmt.Sprintf("alert ip $HOME_NET any -> %s any", ioc.IOC), ""}}
+	}
+	return nil
+}
+
+// ruleEscapeContent Escape a string to be used in a rule content match.
+// Reserved and non printable characters are written as hex bytes.
+func ruleEscapeContent(s string) string {
+	ret := ""
+	for _, c := range []byte(s) {
+		if c == '"' || c == ';' || c == '\\' || c == '|' || c < 0x20 || c > 0x7e {
+			ret += fmt.Sprintf("|%02X|", c)
+			continue
+		}
+		ret += string(c)
+	}
+	return ret
+}
+
+// ruleEscapeMsg Escape a string to be used in a rule msg
+func ruleEscapeMsg(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `;`, `\;`).Replace(s)
+}
+
+// ruleDNSName Convert a domain to its DNS wire format as rule content.
+// Ex: example.com -> |07|example|03|com|00|
+func ruleDNSName(domain string) string {
+	ret := ""
+	for _, label := range strings.Split(strings.TrimSuffix(domain, "."), ".") {
+		ret += fmt.Sprintf("|%02X|%s", len(label), ruleEscapeContent(label))
+	}
+	return ret + "|00|"
+}
+
+// ruleReferenceURL Rule references to urls do not include the scheme
+func ruleReferenceURL(reference string) string {
+	if i := strings.Index(reference, "://"); i != -1 {
+		reference = reference[i+3:]
+	}
+	return strings.NewReplacer(";", "%3B", " ", "%20").Replace(reference)
+}
@@ -0,0 +1,78 @@
+package ioc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrintIOCsSuricata(t *testing.T) {
+	tests := []struct {
+		input []*IOC
+		want  []string
+	}{
+		{
+			[]*IOC{{"example[.]com", Domain}},
+			[]string{
+				`alert dns $HOME_NET any -> any any (msg:"go-ioc Domain example[.]com"; dns.query; content:"example.com"; nocase; bsize:11; reference:url,example.com/article; classtype:trojan-activity; sid:1000000; rev:1;)`,
+				`alert tls $HOME_NET any -> $EXTERNAL_NET any (msg:"go-ioc Domain example[.]com"; flow:established,to_server; tls.sni; content:"example.com"; nocase; bsize:11; reference:url,example.com/article; classtype:trojan-activity; sid:1000001; rev:1;)`,
+			},
+		},
+		{
+			// Different defangs of the same IOC only get one set of rules
+			[]*IOC{{"example[.]com", Domain}, {"example(.)com", Domain}, {"example.com", Domain}},
+			[]string{
+				`alert dns $HOME_NET any -> any any (msg:"go-ioc Domain example[.]com"; dns.query; content:"example.com"; nocase; bsize:11; reference:url,example.com/article; classtype:trojan-activity; sid:1000000; rev:1;)`,
+				`alert tls $HOME_NET any -> $EXTERNAL_NET any (msg:"go-ioc Domain example[.]com"; flow:established,to_server; tls.sni; content:"example.com"; nocase; bsize:11; reference:url,example.com/article; classtype:trojan-activity; sid:1000001; rev:1;)`,
+			},
+		},
+		{
+			[]*IOC{{"hxxp[://]bad[.]com/path;x?a=1", URL}},
+			[]string{
+				`alert http $HOME_NET any -> $EXTERNAL_NET any (msg:"go-ioc URL hxxp[://]bad[.]com/path\;x?a=1"; flow:established,to_server; http.host; content:"bad.com"; nocase; bsize:7; http.uri; content:"/path|3B|x?a=1"; startswith; reference:url,example.com/article; classtype:trojan-activity; sid:1000000; rev:1;)`,
+			},
+		},
+		{
+			[]*IOC{{"1.2.3.4", IPv4}, {"874058e8d8582bf85c115ce319c5b0af", MD5}, {"::1", IPv6}},
+			[]string{
+				`alert ip $HOME_NET any -> 1.2.3.4 any (msg:"go-ioc IPv4 1[.]2[.]3[.]4"; reference:url,example.com/article; classtype:trojan-activity; sid:1000000; rev:1;)`,
+				`alert ip $HOME_NET any -> ::1 any (msg:"go-ioc IPv6 [:][:]1"; reference:url,example.com/article; classtype:trojan-activity; sid:1000001; rev:1;)`,
+			},
+		},
+	}
+
+	for i, test := range tests {
+		got, err := PrintIOCsSuricata(test.input, RuleOptions{Reference: "https://example.com/article"})
+		if err != nil {
+			t.Errorf("Failed test %d: %s", i, err)
+			continue
+		}
+		if want := strings.Join(test.want, "\n"); got != want {
+			t.Errorf("Failed test %d, got:\n%s\nwanted:\n%s", i, got, want)
+		}
+	}
+}
+
+func TestPrintIOCsSnort(t *testing.T) {
+	got, err := PrintIOCsSnort([]*IOC{{"example.com", Domain}, {"http://bad.com/a", URL}}, RuleOptions{SIDStart: 5, Classtype: "bad-unknown", Message: "{ioc}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		`alert udp $HOME_NET any -> any 53 (msg:"example[.]com"; content:"|01 00 00 01 00 00 00 00 00 00|"; depth:10; offset:2; content:"|07|example|03|com|00|"; nocase; distance:0; fast_pattern; classtype:bad-unknown; sid:5; rev:1;)`,
+		`alert tcp $HOME_NET any -> $EXTERNAL_NET 443 (msg:"example[.]com"; flow:established,to_server; content:"|16 03|"; depth:2; content:"example.com"; nocase; distance:0; fast_pattern; classtype:bad-unknown; sid:6; rev:1;)`,
+		`alert tcp $HOME_NET any -> $EXTERNAL_NET $HTTP_PORTS (msg:"hxxp[://]bad[.]com/a"; flow:established,to_server; content:"Host|3A 20|bad.com"; http_header; nocase; content:"/a"; http_uri; depth:2; classtype:bad-unknown; sid:7; rev:1;)`,
+	}, "\n")
+	if got != want {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, want)
+	}
+}
+
+func TestRulesSIDRange(t *testing.T) {
+	iocs := []*IOC{{"1.2.3.4", IPv4}, {"1.2.3.5", IPv4}}
+	if _, err := PrintIOCsSuricata(iocs, RuleOptions{SIDStart: 10, SIDEnd: 11}); err != nil {
+		t.Errorf("SID range should have been large enough: %s", err)
+	}
+	if _, err := PrintIOCsSuricata(iocs, RuleOptions{SIDStart: 10, SIDEnd: 10}); err == nil {
+		t.Errorf("Should have errored on a SID range that is too small")
+	}
+}
@@ -0,0 +1,295 @@
+package ioc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sensorFieldKind How the value of a field of a Zeek or Suricata log is turned in to IOCs
+type sensorFieldKind int
+
+const (
+	sensorIP   sensorFieldKind = iota + 1
+	sensorHost                 // A domain or IP
+	sensorURL
+	sensorHTTPURI // The path of an HTTP request, combined with the host
+	sensorHash
+	sensorFile
+	sensorEmail
+)
+
+// zeekFields The fields of Zeek's conn, dns, http, ssl, and files logs that have indicators
+var zeekFields = map[string]sensorFieldKind{
+	"id.orig_h": sensorIP, "id.resp_h": sensorIP,
+	// dns
+	"query": sensorHost, "answers": sensorHost,
+	// http
+	"host": sensorHost, "uri": sensorHTTPURI, "referrer": sensorURL, "orig_filenames": sensorFile, "resp_filenames": sensorFile,
+	// ssl
+	"server_name": sensorHost,
+	// files
+	"md5": sensorHash, "sha1": sensorHash, "sha256": sensorHash, "filename": sensorFile, "tx_hosts": sensorIP, "rx_hosts": sensorIP,
+}
+
+// eveFields The fields of Suricata's EVE events that have indicators
+var eveFields = map[string]sensorFieldKind{
+	"src_ip": sensorIP, "dest_ip": sensorIP,
+	"dns.rrname": sensorHost, "dns.rdata": sensorHost, "dns.queries.rrname": sensorHost, "dns.answers.rrname": sensorHost, "dns.answers.rdata": sensorHost,
+	"dns.grouped.A": sensorIP, "dns.grouped.AAAA": sensorIP, "dns.grouped.CNAME": sensorHost,
+	"http.hostname": sensorHost, "http.url": sensorHTTPURI, "http.http_refer": sensorURL,
+	"tls.sni":           sensorHost,
+	"fileinfo.filename": sensorFile, "fileinfo.md5": sensorHash, "fileinfo.sha1": sensorHash, "fileinfo.sha256": sensorHash,
+	"smtp.helo": sensorHost, "smtp.mail_from": sensorEmail, "smtp.rcpt_to": sensorEmail,
+	"email.from": sensorEmail, "email.to": sensorEmail, "email.cc": sensorEmail,
+}
+
+// hostIOC Get the IOC of a domain or IP, nil if it is neither or the IP is reserved (private, loopback, etc)
+func hostIOC(host string) *IOC {
+	host = strings.TrimSuffix(strings.TrimSpace(host), ".")
+	if ip := net.ParseIP(host); ip != nil {
+		if isReservedIP(ip) {
+			return nil
+		}
+		return &IOC{IOC: ip.String(), Type: ipType(ip)}
+	}
+	if ioc := ParseIOC(host); ioc.Type == Domain && ioc.IOC == host {
+		return ioc
+	}
+	return nil
+}
+
+// sensorPath The path of a field without its array indexes, ex: dns.answers.rdata
+func sensorPath(segments []string) string {
+	path := []string{}
+	for _, segment := range segments {
+		if _, err := strconv.Atoi(segment); err != nil {
+			path = append(path, segment)
+		}
+	}
+	return strings.Join(path, ".")
+}
+
+// sensorIOCs Get the IOCs from the known fields of a Zeek or Suricata record, each with its field as the "field" Metadata.
+// record is all of the fields, to find the host of HTTP requests.
+func sensorIOCs(fields []logField, record []logField, format string) []*FoundIOC {
+	mapping, hostField := zeekFields, "host"
+	if format == LogEVE {
+		mapping, hostField = eveFields, "http.hostname"
+	}
+
+	iocs := []*FoundIOC{}
+	for _, field := range fields {
+		value := strings.TrimSpace(field.value)
+		found := []*IOC{}
+		switch mapping[sensorPath(field.segments)] {
+		case sensorIP:
+			if ip := net.ParseIP(value); ip != nil {
+				found = append(found, hostIOC(value))
+			}
+		case sensorHost:
+			found = append(found, hostIOC(value))
+		case sensorHTTPURI:
+			if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+				for _, other := range record {
+					if sensorPath(other.segments) == hostField && other.value != "" && strings.HasPrefix(value, "/") {
+						value = "http://" + other.value + value
+					}
+				}
+			}
+			fallthrough
+		case sensorURL:
+			if ioc := ParseIOC(value); ioc.Type == URL && ioc.IOC == value {
+				found = append(found, ioc)
+			}
+		case sensorHash:
+			value = strings.ToLower(value)
+			if ioc := ParseIOC(value); ioc.IOC == value && (ioc.Type == MD5 || ioc.Type == SHA1 || ioc.Type == SHA256 || ioc.Type == SHA512) {
+				found = append(found, ioc)
+			}
+		case sensorFile:
+			if value != "" {
+				found = append(found, &IOC{IOC: value, Type: File})
+			}
+		case sensorEmail:
+			for _, address := range emailAddresses(value) {
+				found = append(found, &IOC{IOC: address, Type: Email})
+			}
+		}
+
+		for _, ioc := range found {
+			if ioc != nil {
+				iocs = append(iocs, &FoundIOC{IOC: ioc.IOC, Type: ioc.Type, Metadata: map[string]string{"field": field.path}})
+			}
+		}
+	}
+	return iocs
+}
+
+// readZeekLogs Read each line of a Zeek TSV log, using the separators and fields in its header
+func readZeekLogs(reader io.Reader, handle func([]logField, map[string]string)) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	separator, setSeparator, unset, empty := "\t", ",", "-", "(empty)"
+	var columns, types []string
+	metadata := map[string]string{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#separator ") {
+			separator = zeekUnescape(strings.TrimPrefix(line, "#separator "))
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			directive := strings.SplitN(line[1:], separator, 2)
+			if len(directive) < 2 {
+				continue
+			}
+			switch directive[0] {
+			case "set_separator":
+				setSeparator = directive[1]
+			case "unset_field":
+				unset = directive[1]
+			case "empty_field":
+				empty = directive[1]
+			case "path":
+				metadata = map[string]string{"log": directive[1]}
+			case "fields":
+				columns = strings.Split(directive[1], separator)
+			case "types":
+				types = strings.Split(directive[1], separator)
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if columns == nil {
+			return fmt.Errorf("missing the #fields header")
+		}
+
+		fields := []logField{}
+		for i, value := range strings.Split(line, separator) {
+			if i >= len(columns) {
+				break
+			}
+			if value == unset || value == empty {
+				continue
+			}
+			values := []string{value}
+			if i < len(types) && (strings.HasPrefix(types[i], "set[") || strings.HasPrefix(types[i], "vector[")) {
+				values = strings.Split(value, setSeparator)
+			}
+			for j, value := range values {
+				path := columns[i]
+				if len(values) > 1 {
+					path = fmt.Sprintf("%s[%d]", columns[i], j)
+				}
+				fields = append(fields, logField{path: path, segments: strings.Split(columns[i], "."), value: zeekUnescape(value)})
+			}
+		}
+		handle(fields, metadata)
+	}
+	return scanner.Err()
+}
+
+// zeekEscape Escaped bytes in Zeek logs, ex: \x09
+var zeekEscape = regexp.MustCompile(`\\x[0-9a-fA-F]{2}`)
+
+func zeekUnescape(value string) string {
+	return zeekEscape.ReplaceAllStringFunc(value, func(escape string) string {
+		b, _ := strconv.ParseUint(escape[2:], 16, 8)
+		return string([]byte{byte(b)})
+	})
+}
+
+var (
+	// syslog5424 An RFC 5424 header: PRI and version, timestamp, host, app, procid, and msgid, then the structured data and message
+	syslog5424 = regexp.MustCompile(`^<\d{1,3}>\d{1,2} (\S+) (\S+) (\S+) (\S+) (\S+) (.*)$`)
+	// syslog3164 An RFC 3164 (BSD) header: an optional PRI, timestamp, host, and tag, then the message.
+	// Log files written by syslog daemons do not have the PRI, and some have an RFC 3339 timestamp.
+	syslog3164 = regexp.MustCompile(`^(?:<\d{1,3}>)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}|\d{4}-\d{2}-\d{2}T\S+) (\S+) (?:([^\s:\[]+)(?:\[\d+\])?: )?(.*)$`)
+)
+
+// readSyslog Read each RFC 3164 or RFC 5424 message, one per line.
+// The message, and each structured data parameter (as sd.id.name), are fields, and the host and app are in the metadata.
+func readSyslog(reader io.Reader, handle func([]logField, map[string]string)) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		metadata := map[string]string{}
+		fields := []logField{}
+		message := line
+		if match := syslog5424.FindStringSubmatch(line); match != nil {
+			metadata["host"], metadata["app"] = match[2], match[3]
+			var params [][3]string
+			params, message = syslogStructuredData(match[6])
+			for _, param := range params {
+				fields = append(fields, logField{path: "sd." + param[0] + "." + param[1], segments: []string{"sd", param[0], param[1]}, value: param[2]})
+			}
+		} else if match := syslog3164.FindStringSubmatch(line); match != nil {
+			metadata["host"], metadata["app"] = match[2], match[3]
+			message = match[4]
+		}
+		for key, value := range metadata {
+			if value == "" || value == "-" {
+				delete(metadata, key)
+			}
+		}
+
+		fields = append([]logField{{path: "message", segments: []string{"message"}, value: strings.TrimPrefix(message, "\ufeff")}}, fields...)
+		handle(fields, metadata)
+	}
+	return scanner.Err()
+}
+
+// syslogStructuredData Parse the structured data elements at the start of an RFC 5424 message, ex: [id name="value"],
+// returning the id, name, and value of each parameter and the message after them
+func syslogStructuredData(data string) ([][3]string, string) {
+	params := [][3]string{}
+	if strings.HasPrefix(data, "-") {
+		return params, strings.TrimPrefix(strings.TrimPrefix(data, "-"), " ")
+	}
+
+	i := 0
+	for i < len(data) && data[i] == '[' {
+		end := strings.IndexAny(data[i:], " ]")
+		if end == -1 {
+			return params, data
+		}
+		id := data[i+1 : i+end]
+		i += end
+
+		// Parameters of the element, name="value" with \", \\, and \] escaped
+		for i < len(data) && data[i] == ' ' {
+			equals := strings.Index(data[i:], `="`)
+			if equals == -1 {
+				return params, data
+			}
+			name := data[i+1 : i+equals]
+			i += equals + 2
+			value := new(strings.Builder)
+			for ; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' && i+1 < len(data) {
+					i++
+				}
+				value.WriteByte(data[i])
+			}
+			params = append(params, [3]string{id, name, value.String()})
+			i++
+		}
+		if i >= len(data) || data[i] != ']' {
+			return params, data
+		}
+		i++
+	}
+	return params, strings.TrimPrefix(data[i:], " ")
+}
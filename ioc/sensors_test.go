@@ -0,0 +1,144 @@
+package ioc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testZeekDNS = `#separator \x09
+#set_separator	,
+#empty_field	(empty)
+#unset_field	-
+#path	dns
+#fields	ts	uid	id.orig_h	id.orig_p	id.resp_h	id.resp_p	query	answers	TTLs
+#types	time	string	addr	port	addr	port	string	vector[string]	vector[interval]
+1583230953.000000	C1	10.0.0.5	50000	8.8.8.8	53	update.evil.com	cdn.evil.net,93.184.216.34	60.0,60.0
+1583230954.000000	C2	10.0.0.5	50001	10.0.0.1	53	wpad	-	-
+#close	2020-03-03-10-30-00
+`
+
+const testZeekFiles = `{"_path":"files","ts":1583230955.0,"fuid":"F1","tx_hosts":["93.184.216.34"],"rx_hosts":["10.0.0.5"],"filename":"invoice.exe","md5":"874058E8D8582BF85C115CE319C5B0AF","sha256":"-"}
+{"_path":"http","ts":1583230955.0,"id.orig_h":"10.0.0.5","id.resp_h":"93.184.216.34","host":"update.evil.com","uri":"/payload.bin","referrer":"http://lure.evil.org/","user_agent":"curl 1.2.3.4"}
+`
+
+const testEVE = `{"timestamp":"2020-03-03T10:22:33.000000+0000","event_type":"dns","src_ip":"10.0.0.5","dest_ip":"8.8.8.8","dns":{"type":"answer","rrname":"update.evil.com","answers":[{"rrname":"update.evil.com","rrtype":"A","rdata":"93.184.216.34"}],"grouped":{"CNAME":["cdn.evil.net"]}}}
+{"timestamp":"2020-03-03T10:22:34.000000+0000","event_type":"fileinfo","src_ip":"93.184.216.34","dest_ip":"10.0.0.5","http":{"hostname":"update.evil.com","url":"/payload.bin","http_user_agent":"see evil.org"},"fileinfo":{"filename":"/payload.bin","sha256":"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}}
+{"timestamp":"2020-03-03T10:22:35.000000+0000","event_type":"tls","src_ip":"10.0.0.5","dest_ip":"8.8.4.4","tls":{"sni":"c2.evil.org","ja3":{"hash":"e7d705a3286e19ea42f587b344ee6865"}}}
+`
+
+func TestGetIOCsFromSensorLogs(t *testing.T) {
+	metadata := func(log, record, field string) map[string]string {
+		return map[string]string{"log": log, "record": record, "field": field}
+	}
+	tests := []struct {
+		name    string
+		logs    string
+		format  string
+		options LogOptions
+		want    []*FoundIOC
+	}{
+		{
+			"zeek tsv",
+			testZeekDNS,
+			LogZeek,
+			LogOptions{},
+			[]*FoundIOC{
+				{IOC: "8.8.8.8", Type: IPv4, Metadata: metadata("dns", "1", "id.resp_h")},
+				{IOC: "update.evil.com", Type: Domain, Metadata: metadata("dns", "1", "query")},
+				{IOC: "cdn.evil.net", Type: Domain, Metadata: metadata("dns", "1", "answers[0]")},
+				{IOC: "93.184.216.34", Type: IPv4, Metadata: metadata("dns", "1", "answers[1]")},
+			},
+		},
+		{
+			"zeek json",
+			testZeekFiles,
+			"",
+			LogOptions{Exclude: []string{"referrer"}},
+			[]*FoundIOC{
+				{IOC: "invoice.exe", Type: File, Metadata: metadata("files", "1", "filename")},
+				{IOC: "874058e8d8582bf85c115ce319c5b0af", Type: MD5, Metadata: metadata("files", "1", "md5")},
+				{IOC: "93.184.216.34", Type: IPv4, Metadata: metadata("files", "1", "tx_hosts[0]")},
+				{IOC: "update.evil.com", Type: Domain, Metadata: metadata("http", "2", "host")},
+				{IOC: "93.184.216.34", Type: IPv4, Metadata: metadata("http", "2", "id.resp_h")},
+				{IOC: "http://update.evil.com/payload.bin", Type: URL, Metadata: metadata("http", "2", "uri")},
+			},
+		},
+		{
+			"eve",
+			testEVE,
+			"",
+			LogOptions{},
+			[]*FoundIOC{
+				{IOC: "8.8.8.8", Type: IPv4, Metadata: metadata("dns", "1", "dest_ip")},
+				{IOC: "93.184.216.34", Type: IPv4, Metadata: metadata("dns", "1", "dns.answers[0].rdata")},
+				{IOC: "update.evil.com", Type: Domain, Metadata: metadata("dns", "1", "dns.answers[0].rrname")},
+				{IOC: "cdn.evil.net", Type: Domain, Metadata: metadata("dns", "1", "dns.grouped.CNAME[0]")},
+				{IOC: "update.evil.com", Type: Domain, Metadata: metadata("dns", "1", "dns.rrname")},
+				{IOC: "/payload.bin", Type: File, Metadata: metadata("fileinfo", "2", "fileinfo.filename")},
+				{IOC: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", Type: SHA256, Metadata: metadata("fileinfo", "2", "fileinfo.sha256")},
+				{IOC: "update.evil.com", Type: Domain, Metadata: metadata("fileinfo", "2", "http.hostname")},
+				{IOC: "http://update.evil.com/payload.bin", Type: URL, Metadata: metadata("fileinfo", "2", "http.url")},
+				{IOC: "93.184.216.34", Type: IPv4, Metadata: metadata("fileinfo", "2", "src_ip")},
+				{IOC: "8.8.4.4", Type: IPv4, Metadata: metadata("tls", "3", "dest_ip")},
+				{IOC: "c2.evil.org", Type: Domain, Metadata: metadata("tls", "3", "tls.sni")},
+			},
+		},
+		{
+			"eve selectors",
+			testEVE,
+			LogEVE,
+			LogOptions{Include: []string{"tls"}},
+			[]*FoundIOC{{IOC: "c2.evil.org", Type: Domain, Metadata: metadata("tls", "3", "tls.sni")}},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := GetIOCsFromLogs(strings.NewReader(test.logs), test.format, test.options)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, wanted %v", test.name, got, test.want)
+			for _, ioc := range got {
+				t.Log(ioc.IOC, ioc.Type, ioc.Metadata)
+			}
+		}
+	}
+}
+
+func TestGetIOCsFromSyslog(t *testing.T) {
+	logs := `<34>1 2020-03-03T10:22:33.003Z mail.example.org sshd 1234 ID47 [origin ip="1[.]2[.]3[.]4"][meta note="a \"quoted\] evil[.]com"] Failed login from 5[.]6[.]7[.]8
+Mar  3 10:22:33 web01 nginx[99]: request to bad[.]net
+<13>Mar  3 10:22:34 web01 cron job for other[.]org
+`
+	if got := DetectLogFormat(strings.SplitN(logs, "\n", 2)[0]); got != LogSyslog {
+		t.Errorf("Detected as %s", got)
+	}
+	got, err := GetIOCsFromLogs(strings.NewReader(logs), "", LogOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := func(record, field, host, app string) map[string]string {
+		ret := map[string]string{"record": record, "field": field, "host": host}
+		if app != "" {
+			ret["app"] = app
+		}
+		return ret
+	}
+	want := []*FoundIOC{
+		{IOC: "5[.]6[.]7[.]8", Type: IPv4, Metadata: metadata("1", "message", "mail.example.org", "sshd")},
+		{IOC: "1[.]2[.]3[.]4", Type: IPv4, Metadata: metadata("1", "sd.origin.ip", "mail.example.org", "sshd")},
+		{IOC: "evil[.]com", Type: Domain, Metadata: metadata("1", "sd.meta.note", "mail.example.org", "sshd")},
+		{IOC: "bad[.]net", Type: Domain, Metadata: metadata("2", "message", "web01", "nginx")},
+		{IOC: "other[.]org", Type: Domain, Metadata: metadata("3", "message", "web01", "")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+		for _, ioc := range got {
+			t.Log(ioc.IOC, ioc.Type, ioc.Metadata)
+		}
+	}
+}
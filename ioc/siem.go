@@ -0,0 +1,143 @@
+package ioc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// siemField A field in a SIEM that holds IOCs of some types
+type siemField struct {
+	field string
+	types []Type
+}
+
+// splunkFields Splunk Common Information Model fields
+var splunkFields = []siemField{
+	{"query", []Type{Domain}},
+	{"url", []Type{URL}},
+	{"dest_ip", []Type{IPv4, IPv6}},
+	{"file_hash", []Type{MD5, SHA1, SHA256, SHA512}},
+	{"file_name", []Type{File}},
+	{"src_user", []Type{Email}},
+}
+
+// elasticFields Elastic Common Schema fields
+var elasticFields = []siemField{
+	{"dns.question.name", []Type{Domain}},
+	{"url.original", []Type{URL}},
+	{"destination.ip", []Type{IPv4, IPv6}},
+	{"file.hash.md5", []Type{MD5}},
+	{"file.hash.sha1", []Type{SHA1}},
+	{"file.hash.sha256", []Type{SHA256}},
+	{"file.hash.sha512", []Type{SHA512}},
+	{"file.name", []Type{File}},
+	{"email.from.address", []Type{Email}},
+}
+
+// microsoftTables Microsoft Defender / Sentinel advanced hunting tables and the fields holding IOCs
+var microsoftTables = []struct {
+	table  string
+	fields []siemField
+	// operator Used to match each field, has_any matches hosts inside urls
+	operator map[string]string
+}{
+	{
+		"DeviceNetworkEvents",
+		[]siemField{
+			{"RemoteUrl", []Type{Domain, URL}},
+			{"RemoteIP", []Type{IPv4, IPv6}},
+		},
+		map[string]string{"RemoteUrl": "has_any", "RemoteIP": "in"},
+	},
+	{
+		"DeviceFileEvents",
+		[]siemField{
+			{"MD5", []Type{MD5}},
+			{"SHA1", []Type{SHA1}},
+			{"SHA256", []Type{SHA256}},
+			{"FileName", []Type{File}},
+		},
+		map[string]string{"MD5": "in~", "SHA1": "in~", "SHA256": "in~", "FileName": "in~"},
+	},
+	{
+		"EmailEvents",
+		[]siemField{{"SenderFromAddress", []Type{Email}}},
+		map[string]string{"SenderFromAddress": "in~"},
+	},
+}
+
+// PrintIOCsSplunk Takes []IOC and returns a Splunk SPL search using CIM field names
+func PrintIOCsSplunk(iocs []*IOC) string {
+	clauses := siemClauses(iocs, splunkFields, func(field string, values []string) string {
+		return fmt.Sprintf("%s IN (%s)", field, strings.Join(values, ", "))
+	})
+	return strings.Join(clauses, " OR ")
+}
+
+// PrintIOCsElasticKQL Takes []IOC and returns an Elastic KQL query using ECS field names
+func PrintIOCsElasticKQL(iocs []*IOC) string {
+	clauses := siemClauses(iocs, elasticFields, func(field string, values []string) string {
+		return fmt.Sprintf("%s:(%s)", field, strings.Join(values, " or "))
+	})
+	return strings.Join(clauses, " or ")
+}
+
+// PrintIOCsElasticEQL Takes []IOC and returns an Elastic EQL query using ECS field names
+func PrintIOCsElasticEQL(iocs []*IOC) string {
+	clauses := siemClauses(iocs, elasticFields, func(field string, values []string) string {
+		return fmt.Sprintf("%s in (%s)", field, strings.Join(values, ", "))
+	})
+	if len(clauses) == 0 {
+		return ""
+	}
+	return "any where " + strings.Join(clauses, " or ")
+}
+
+// PrintIOCsMicrosoftKQL Takes []IOC and returns a Microsoft Defender / Sentinel KQL query
+// searching the network, file, and email event tables.
+func PrintIOCsMicrosoftKQL(iocs []*IOC) string {
+	queries := []string{}
+	for _, table := range microsoftTables {
+		clauses := siemClauses(iocs, table.fields, func(field string, values []string) string {
+			return fmt.Sprintf("%s %s (%s)", field, table.operator[field], strings.Join(values, ", "))
+		})
+		if len(clauses) > 0 {
+			queries = append(queries, fmt.Sprintf("%s | where %s", table.table, strings.Join(clauses, " or ")))
+		}
+	}
+
+	switch len(queries) {
+	case 0:
+		return ""
+	case 1:
+		return queries[0]
+	}
+	return "union\n(" + strings.Join(queries, "),\n(") + ")"
+}
+
+// siemClauses Build a clause for each field that has IOCs using the backend's match format
+func siemClauses(iocs []*IOC, fields []siemField, match func(field string, values []string) string) []string {
+	values := fangedValuesByType(iocs)
+
+	clauses := []string{}
+	for _, field := range fields {
+		quoted := []string{}
+		for _, t := range field.types {
+			for _, value := range values[t] {
+				if t == MD5 || t == SHA1 || t == SHA256 || t == SHA512 {
+					value = strings.ToLower(value)
+				}
+				quoted = append(quoted, siemQuote(value))
+			}
+		}
+		if len(quoted) > 0 {
+			clauses = append(clauses, match(field.field, quoted))
+		}
+	}
+	return clauses
+}
+
+// siemQuote Quote a value as a double quoted string, which SPL, KQL, and EQL all escape with backslashes
+func siemQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,42 @@
+package ioc
+
+import "testing"
+
+func TestSIEMQueries(t *testing.T) {
+	iocs := []*IOC{
+		{"example[.]com", Domain},
+		{"bad.com", Domain},
+		{"bad.com", Domain},
+		{"hxxp[://]example[.]com/a\"b", URL},
+		{"1[.]2[.]3[.]4", IPv4},
+		{"874058E8D8582BF85C115CE319C5B0AF", MD5},
+		{"test[AT]example[.]com", Email},
+	}
+
+	tests := []struct {
+		name  string
+		print func([]*IOC) string
+		want  string
+	}{
+		{"splunk", PrintIOCsSplunk,
+			`query IN ("example.com", "bad.com") OR url IN ("http://example.com/a\"b") OR dest_ip IN ("1.2.3.4") OR file_hash IN ("874058e8d8582bf85c115ce319c5b0af") OR src_user IN ("test@example.com")`},
+		{"elastic-kql", PrintIOCsElasticKQL,
+			`dns.question.name:("example.com" or "bad.com") or url.original:("http://example.com/a\"b") or destination.ip:("1.2.3.4") or file.hash.md5:("874058e8d8582bf85c115ce319c5b0af") or email.from.address:("test@example.com")`},
+		{"elastic-eql", PrintIOCsElasticEQL,
+			`any where dns.question.name in ("example.com", "bad.com") or url.original in ("http://example.com/a\"b") or destination.ip in ("1.2.3.4") or file.hash.md5 in ("874058e8d8582bf85c115ce319c5b0af") or email.from.address in ("test@example.com")`},
+		{"microsoft-kql", PrintIOCsMicrosoftKQL,
+			"union\n" +
+				`(DeviceNetworkEvents | where RemoteUrl has_any ("example.com", "bad.com", "http://example.com/a\"b") or RemoteIP in ("1.2.3.4")),` + "\n" +
+				`(DeviceFileEvents | where MD5 in~ ("874058e8d8582bf85c115ce319c5b0af")),` + "\n" +
+				`(EmailEvents | where SenderFromAddress in~ ("test@example.com"))`},
+	}
+
+	for _, test := range tests {
+		if got := test.print(iocs); got != test.want {
+			t.Errorf("%s got:\n%s\nwanted:\n%s", test.name, got, test.want)
+		}
+		if got := test.print(nil); got != "" {
+			t.Errorf("%s should be empty with no IOCs, got %s", test.name, got)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package ioc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SigmaOptions Metadata to include in Sigma rules
+type SigmaOptions struct {
+	Title       string // Defaults to "go-ioc IOCs"
+	Author      string
+	Description string
+	Reference   string
+	Level       string    // Defaults to high
+	Date        time.Time // Defaults to now
+}
+
+// sigmaSelection Values of IOCs of some types to match on a field
+type sigmaSelection struct {
+	name  string
+	field string
+	types []Type
+	// prefix Added to each value, ex: Sysmon hashes look like MD5=...
+	prefix map[Type]string
+}
+
+// sigmaRules The Sigma rules we generate, each rule gets a selection per field
+var sigmaRules = []struct {
+	title      string
+	logsource  [][2]string
+	selections []sigmaSelection
+}{
+	{
+		"DNS Query",
+		[][2]string{{"category", "dns_query"}, {"product", "windows"}},
+		[]sigmaSelection{{name: "selection", field: "QueryName", types: []Type{Domain}}},
+	},
+	{
+		"Proxy",
+		[][2]string{{"category", "proxy"}},
+		[]sigmaSelection{
+			{name: "selection_uri", field: "c-uri", types: []Type{URL}},
+			{name: "selection_host", field: "cs-host", types: []Type{Domain}},
+		},
+	},
+	{
+		"Network Connection",
+		[][2]string{{"category", "network_connection"}, {"product", "windows"}},
+		[]sigmaSelection{
+			{name: "selection_ip", field: "DestinationIp", types: []Type{IPv4, IPv6}},
+			{name: "selection_hostname", field: "DestinationHostname", types: []Type{Domain}},
+		},
+	},
+	{
+		"Process Creation Hash",
+		[][2]string{{"category", "process_creation"}, {"product", "windows"}},
+		[]sigmaSelection{{name: "selection", field: "Hashes|contains", types: []Type{MD5, SHA1, SHA256}, prefix: map[Type]string{MD5: "MD5=", SHA1: "SHA1=", SHA256: "SHA256="}}},
+	},
+}
+
+// PrintIOCsSigma Takes []IOC and returns Sigma rules for them, separated by ---.
+// There is a rule for each logsource: DNS queries, proxy logs, network connections, and process creation hashes.
+// Rules with no matching IOCs are skipped.
+func PrintIOCsSigma(iocs []*IOC, options SigmaOptions) string {
+	if options.Title == "" {
+		options.Title = "go-ioc IOCs"
+	}
+	if options.Level == "" {
+		options.Level = "high"
+	}
+	if options.Date.IsZero() {
+		options.Date = time.Now()
+	}
+
+	values := fangedValuesByType(iocs)
+
+	rules := []string{}
+	for _, rule := range sigmaRules {
+		selections := ""
+		names := []string{}
+		for _, selection := range rule.selections {
+			matches := ""
+			for _, t := range selection.types {
+				for _, value := range values[t] {
+					if t == MD5 || t == SHA1 || t == SHA256 {
+						value = strings.ToUpper(value)
+					}
+					matches += fmt.Sprintf("            - %s\n", sigmaQuote(selection.prefix[t]+sigmaEscape(value)))
+				}
+			}
+			if matches == "" {
+				continue
+			}
+			names = append(names, selection.name)
+			selections += fmt.Sprintf("    %s:\n        %s:\n%s", selection.name, selection.field, matches)
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		condition := names[0]
+		if len(names) > 1 {
+			condition = "1 of selection_*"
+		}
+
+		ret := fmt.Sprintf("title: %s\n", sigmaQuote(options.Title+" - "+rule.title))
+		ret += fmt.Sprintf("id: %s\n", mustNewUUID())
+		ret += "status: experimental\n"
+		if options.Description != "" {
+			ret += fmt.Sprintf("description: %s\n", sigmaQuote(options.Description))
+		}
+		if options.Reference != "" {
+			ret += fmt.Sprintf("references:\n    - %s\n", sigmaQuote(options.Reference))
+		}
+		if options.Author != "" {
+			ret += fmt.Sprintf("author: %s\n", sigmaQuote(options.Author))
+		}
+		ret += fmt.Sprintf("date: %s\n", options.Date.Format("2006-01-02"))
+		ret += "logsource:\n"
+		for _, field := range rule.logsource {
+			ret += fmt.Sprintf("    %s: %s\n", field[0], field[1])
+		}
+		ret += "detection:\n" + selections
+		ret += fmt.Sprintf("    condition: %s\n", condition)
+		ret += "falsepositives:\n    - Unknown\n"
+		ret += fmt.Sprintf("level: %s", options.Level)
+
+		rules = append(rules, ret)
+	}
+
+	return strings.Join(rules, "\n---\n")
+}
+
+// sigmaEscape Escape the Sigma wildcards in a value so it matches exactly
+func sigmaEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(s)
+}
+
+// sigmaQuote Quote a string as a single quoted YAML scalar
+func sigmaQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,104 @@
+package ioc
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestPrintIOCsSigma(t *testing.T) {
+	iocs := []*IOC{
+		{"example[.]com", Domain},
+		{"hxxp[://]example[.]com/a?b=*", URL},
+		{"874058e8d8582bf85c115ce319c5b0af", MD5},
+	}
+	got := PrintIOCsSigma(iocs, SigmaOptions{
+		Title:     "Bad Report",
+		Author:    "O'Brien",
+		Reference: "https://example.com/report",
+		Date:      time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	// IDs are random
+	got = regexp.MustCompile(`(?m)^id: .*$`).ReplaceAllString(got, "id: x")
+
+	want := `title: 'Bad Report - DNS Query'
+id: x
+status: experimental
+references:
+    - 'https://example.com/report'
+author: 'O''Brien'
+date: 2020-03-01
+logsource:
+    category: dns_query
+    product: windows
+detection:
+    selection:
+        QueryName:
+            - 'example.com'
+    condition: selection
+falsepositives:
+    - Unknown
+level: high
+---
+title: 'Bad Report - Proxy'
+id: x
+status: experimental
+references:
+    - 'https://example.com/report'
+author: 'O''Brien'
+date: 2020-03-01
+logsource:
+    category: proxy
+detection:
+    selection_uri:
+        c-uri:
+            - 'http://example.com/a\?b=\*'
+    selection_host:
+        cs-host:
+            - 'example.com'
+    condition: 1 of selection_*
+falsepositives:
+    - Unknown
+level: high
+---
+title: 'Bad Report - Network Connection'
+id: x
+status: experimental
+references:
+    - 'https://example.com/report'
+author: 'O''Brien'
+date: 2020-03-01
+logsource:
+    category: network_connection
+    product: windows
+detection:
+    selection_hostname:
+        DestinationHostname:
+            - 'example.com'
+    condition: selection_hostname
+falsepositives:
+    - Unknown
+level: high
+---
+title: 'Bad Report - Process Creation Hash'
+id: x
+status: experimental
+references:
+    - 'https://example.com/report'
+author: 'O''Brien'
+date: 2020-03-01
+logsource:
+    category: process_creation
+    product: windows
+detection:
+    selection:
+        Hashes|contains:
+            - 'MD5=874058E8D8582BF85C115CE319C5B0AF'
+    condition: selection
+falsepositives:
+    - Unknown
+level: high`
+	if got != want {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, want)
+	}
+}
@@ -0,0 +1,131 @@
+package ioc
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Stats Counts of IOCs in total, by type, and by source.
+// Fanged and Defanged only count types that can be defanged (domains, emails, IPs, URLs).
+type Stats struct {
+	Count    int           `json:"count"`
+	Fanged   int           `json:"fanged"`
+	Defanged int           `json:"defanged"`
+	Types    []TypeStats   `json:"types"`
+	Sources  []SourceStats `json:"sources,omitempty"`
+}
+
+// TypeStats Counts of IOCs of a single type
+type TypeStats struct {
+	Type     string `json:"type"`
+	Count    int    `json:"count"`
+	Fanged   int    `json:"fanged"`
+	Defanged int    `json:"defanged"`
+}
+
+// SourceStats Counts of IOCs found in a single source
+type SourceStats struct {
+	Source   string      `json:"source"`
+	Count    int         `json:"count"`
+	Fanged   int         `json:"fanged"`
+	Defanged int         `json:"defanged"`
+	Types    []TypeStats `json:"types"`
+}
+
+// GetIOCsStats Given []FoundIOC get the stats about them.
+// Types are in the order of Types, sources are in the order they first appear.
+// Sources is only filled if at least one IOC has a Source.
+func GetIOCsStats(iocs []*FoundIOC) Stats {
+	stats := Stats{Types: typeStats(iocs)}
+	for _, t := range stats.Types {
+		stats.Count += t.Count
+		stats.Fanged += t.Fanged
+		stats.Defanged += t.Defanged
+	}
+
+	// Group by source
+	sources := []string{}
+	bySource := map[string][]*FoundIOC{}
+	hasSource := false
+	for _, ioc := range iocs {
+		if _, ok := bySource[ioc.Source]; !ok {
+			sources = append(sources, ioc.Source)
+		}
+		bySource[ioc.Source] = append(bySource[ioc.Source], ioc)
+		hasSource = hasSource || ioc.Source != ""
+	}
+	if !hasSource {
+		return stats
+	}
+
+	for _, source := range sources {
+		sourceStats := SourceStats{Source: source, Types: typeStats(bySource[source])}
+		for _, t := range sourceStats.Types {
+			sourceStats.Count += t.Count
+			sourceStats.Fanged += t.Fanged
+			sourceStats.Defanged += t.Defanged
+		}
+		stats.Sources = append(stats.Sources, sourceStats)
+	}
+
+	return stats
+}
+
+// typeStats Count the IOCs of each type, skipping types with no IOCs
+func typeStats(iocs []*FoundIOC) []TypeStats {
+	stats := []TypeStats{}
+	for _, t := range Types {
+		typeStats := TypeStats{Type: t.String()}
+		_, fangable := defangReplacements[t]
+		for _, ioc := range iocs {
+			if ioc.Type != t {
+				continue
+			}
+			typeStats.Count++
+			if !fangable {
+				continue
+			}
+			if ioc.IsFanged() {
+				typeStats.Fanged++
+			} else {
+				typeStats.Defanged++
+			}
+		}
+		if typeStats.Count > 0 {
+			stats = append(stats, typeStats)
+		}
+	}
+	return stats
+}
+
+// PrintIOCsStatsJSON Given iocs print the stats associated with them as JSON
+func PrintIOCsStatsJSON(iocs []*FoundIOC) string {
+	// Marshalling our own struct of strings and ints can not fail
+	ret, _ := json.MarshalIndent(GetIOCsStats(iocs), "", "  ")
+	return string(ret)
+}
+
+// PrintIOCsStatsCSV Given iocs print the stats associated with them as CSV.
+// There is a row for each type, and a "total" row, first for all IOCs (with an empty source) and then for each source.
+func PrintIOCsStatsCSV(iocs []*FoundIOC) string {
+	stats := GetIOCsStats(iocs)
+
+	ret := new(strings.Builder)
+	w := csv.NewWriter(ret)
+	w.Write([]string{"source", "type", "count", "fanged", "defanged"})
+	writeRows := func(source string, types []TypeStats, count, fanged, defanged int) {
+		for _, t := range types {
+			w.Write([]string{source, t.Type, fmt.Sprint(t.Count), fmt.Sprint(t.Fanged), fmt.Sprint(t.Defanged)})
+		}
+		w.Write([]string{source, "total", fmt.Sprint(count), fmt.Sprint(fanged), fmt.Sprint(defanged)})
+	}
+	writeRows("", stats.Types, stats.Count, stats.Fanged, stats.Defanged)
+	for _, source := range stats.Sources {
+		writeRows(source.Source, source.Types, source.Count, source.Fanged, source.Defanged)
+	}
+	w.Flush()
+
+	return strings.TrimSuffix(ret.String(), "\n")
+}
@@ -0,0 +1,89 @@
+package ioc
+
+import (
+	"reflect"
+	"testing"
+)
+
+var statsTestIOCs = []*FoundIOC{
+	{IOC: "example[.]com", Type: Domain, Source: "a.txt"},
+	{IOC: "example.org", Type: Domain, Source: "a.txt"},
+	{IOC: "874058e8d8582bf85c115ce319c5b0af", Type: MD5, Source: "a.txt"},
+	{IOC: "1[.]2[.]3[.]4", Type: IPv4, Source: "b.txt"},
+	{IOC: "bad.com", Type: Domain, Source: "b.txt"},
+}
+
+func TestGetIOCsStatsBreakdown(t *testing.T) {
+	want := Stats{
+		Count: 5, Fanged: 2, Defanged: 2,
+		Types: []TypeStats{
+			{Type: "MD5", Count: 1},
+			{Type: "Domain", Count: 3, Fanged: 2, Defanged: 1},
+			{Type: "IPv4", Count: 1, Defanged: 1},
+		},
+		Sources: []SourceStats{
+			{Source: "a.txt", Count: 3, Fanged: 1, Defanged: 1, Types: []TypeStats{
+				{Type: "MD5", Count: 1},
+				{Type: "Domain", Count: 2, Fanged: 1, Defanged: 1},
+			}},
+			{Source: "b.txt", Count: 2, Fanged: 1, Defanged: 1, Types: []TypeStats{
+				{Type: "Domain", Count: 1, Fanged: 1},
+				{Type: "IPv4", Count: 1, Defanged: 1},
+			}},
+		},
+	}
+	if got := GetIOCsStats(statsTestIOCs); !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%+v\nwanted:\n%+v", got, want)
+	}
+
+	// No sources
+	if got := GetIOCsStats([]*FoundIOC{{IOC: "1.2.3.4", Type: IPv4}}); got.Sources != nil {
+		t.Errorf("Expected no sources, got %+v", got.Sources)
+	}
+}
+
+func TestPrintIOCsStats(t *testing.T) {
+	want := "MD5: 1\nDomain: 3\nIPv4: 1\n"
+	// Run multiple times as map iteration used to make this nondeterministic
+	for i := 0; i < 10; i++ {
+		if got := PrintIOCsStats(ToIOCs(statsTestIOCs)); got != want {
+			t.Fatalf("got:\n%s\nwanted:\n%s", got, want)
+		}
+	}
+}
+
+func TestPrintIOCsStatsCSV(t *testing.T) {
+	want := `source,type,count,fanged,defanged
+,MD5,1,0,0
+,Domain,3,2,1
+,IPv4,1,0,1
+,total,5,2,2
+a.txt,MD5,1,0,0
+a.txt,Domain,2,1,1
+a.txt,total,3,1,1
+b.txt,Domain,1,1,0
+b.txt,IPv4,1,0,1
+b.txt,total,2,1,1`
+	if got := PrintIOCsStatsCSV(statsTestIOCs); got != want {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, want)
+	}
+}
+
+func TestPrintIOCsStatsJSON(t *testing.T) {
+	want := `{
+  "count": 1,
+  "fanged": 0,
+  "defanged": 1,
+  "types": [
+    {
+      "type": "URL",
+      "count": 1,
+      "fanged": 0,
+      "defanged": 1
+    }
+  ]
+}`
+	if got := PrintIOCsStatsJSON([]*FoundIOC{{IOC: "hxxp://example[.]com", Type: URL}}); got != want {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, want)
+	}
+}
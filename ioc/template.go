@@ -0,0 +1,77 @@
+package ioc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs Helper functions available in templates given to PrintIOCsTemplate
+var TemplateFuncs = template.FuncMap{
+	// fang Get the fanged value of an IOC
+	"fang": func(ioc *FoundIOC) string {
+		return ioc.Fang().IOC
+	},
+	// defang Get the standard defanged value of an IOC
+	"defang": func(ioc *FoundIOC) string {
+		return ioc.Fang().Defang().IOC
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	// typeName Get the name of the type of an IOC, or of a Type
+	"typeName": func(v interface{}) (string, error) {
+		switch v := v.(type) {
+		case *FoundIOC:
+			return v.Type.String(), nil
+		case *IOC:
+			return v.Type.String(), nil
+		case Type:
+			return v.String(), nil
+		}
+		return "", fmt.Errorf("typeName requires an IOC or Type, got %T", v)
+	},
+	// json Encode a value as JSON
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// csvEscape Quote a value if it needs to be quoted in a csv field
+	"csvEscape": func(s string) string {
+		if strings.ContainsAny(s, ",\"\r\n") {
+			return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+		}
+		return s
+	},
+}
+
+// PrintIOCsTemplate Takes []FoundIOC and prints them using a text/template with the TemplateFuncs helpers.
+// If perIOC is true the template is executed for each IOC (as the dot) and each result is put on its own line,
+// otherwise the template is executed once with the []*FoundIOC as the dot.
+//
+// Ex: {{defang .}},{{typeName .}}
+func PrintIOCsTemplate(iocs []*FoundIOC, text string, perIOC bool) (string, error) {
+	tmpl, err := template.New("iocs").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	if !perIOC {
+		ret := new(bytes.Buffer)
+		if err := tmpl.Execute(ret, iocs); err != nil {
+			return "", err
+		}
+		return ret.String(), nil
+	}
+
+	lines := []string{}
+	for _, ioc := range iocs {
+		line := new(bytes.Buffer)
+		if err := tmpl.Execute(line, ioc); err != nil {
+			return "", err
+		}
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n"), nil
+}
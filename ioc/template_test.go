@@ -0,0 +1,43 @@
+package ioc
+
+import (
+	"testing"
+)
+
+func TestPrintIOCsTemplate(t *testing.T) {
+	iocs := FoundIOCs([]*IOC{
+		{"example.com", Domain},
+		{"hxxp[://]example[.]com/a,b", URL},
+		{"CVE-2020-1234", CVE},
+	}, "")
+
+	tests := []struct {
+		template string
+		perIOC   bool
+		want     string
+	}{
+		{`{{defang .}},{{typeName .}}`, true, "example[.]com,Domain\nhxxp[://]example[.]com/a,b,URL\nCVE-2020-1234,CVE"},
+		{`{{csvEscape (fang .)}}|{{upper (typeName .Type)}}`, true, "example.com|DOMAIN\n\"http://example.com/a,b\"|URL\nCVE-2020-1234|CVE"},
+		{`{{json (fang .)}}`, true, "\"example.com\"\n\"http://example.com/a,b\"\n\"CVE-2020-1234\""},
+		{`{{len .}} IOCs:{{range .}} {{.IOC}}{{end}}`, false, "3 IOCs: example.com hxxp[://]example[.]com/a,b CVE-2020-1234"},
+	}
+
+	for _, test := range tests {
+		got, err := PrintIOCsTemplate(iocs, test.template, test.perIOC)
+		if err != nil {
+			t.Errorf("Template %s failed: %s", test.template, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Template %s got:\n%s\nwanted:\n%s", test.template, got, test.want)
+		}
+	}
+
+	// Errors
+	if _, err := PrintIOCsTemplate(iocs, `{{.IOC`, true); err == nil {
+		t.Errorf("Should have failed to parse the template")
+	}
+	if _, err := PrintIOCsTemplate(iocs, `{{typeName "x"}}`, true); err == nil {
+		t.Errorf("Should have failed to execute the template")
+	}
+}
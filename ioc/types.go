@@ -18,6 +18,64 @@ func (ioc *IOC) String() string {
 	return ioc.IOC + "|" + ioc.Type.String()
 }
 
+// FoundIOC An IOC and where it was found, returned when getting IOCs from files, emails, logs, and packet captures
+type FoundIOC struct {
+	IOC  string
+	Type Type
+	// Source Where the IOC was found (URL, file path, etc).  Empty if unknown.
+	Source string
+	// Metadata Extra information about where in the source the IOC was found, ex: the email header it came from
+	Metadata map[string]string
+}
+
+// FoundIOCs Takes []IOC and returns them as found in the source
+func FoundIOCs(iocs []*IOC, source string) []*FoundIOC {
+	ret := make([]*FoundIOC, 0, len(iocs))
+	for _, ioc := range iocs {
+		ret = append(ret, &FoundIOC{IOC: ioc.IOC, Type: ioc.Type, Source: source})
+	}
+	return ret
+}
+
+// ToIOCs Takes []FoundIOC and returns the IOCs, without where they were found
+func ToIOCs(found []*FoundIOC) []*IOC {
+	ret := make([]*IOC, 0, len(found))
+	for _, ioc := range found {
+		ret = append(ret, ioc.ToIOC())
+	}
+	return ret
+}
+
+// String Takes a FoundIOC and prints in csv form: IOC|Type
+func (found *FoundIOC) String() string {
+	return found.ToIOC().String()
+}
+
+// ToIOC Get the IOC, without where it was found
+func (found *FoundIOC) ToIOC() *IOC {
+	return &IOC{IOC: found.IOC, Type: found.Type}
+}
+
+// Fang Fang the IOC (see IOC.Fang), keeping where it was found
+func (found *FoundIOC) Fang() *FoundIOC {
+	return found.with(found.ToIOC().Fang())
+}
+
+// Defang Defang the IOC (see IOC.Defang), keeping where it was found
+func (found *FoundIOC) Defang() *FoundIOC {
+	return found.with(found.ToIOC().Defang())
+}
+
+// IsFanged Check if the IOC is fanged, see IOC.IsFanged
+func (found *FoundIOC) IsFanged() bool {
+	return found.ToIOC().IsFanged()
+}
+
+// with Get the IOC as found in the same place
+func (found *FoundIOC) with(ioc *IOC) *FoundIOC {
+	return &FoundIOC{IOC: ioc.IOC, Type: ioc.Type, Source: found.Source, Metadata: found.Metadata}
+}
+
 // Type Type of IOC (bitcoin, sha1, etc)
 type Type int
 
@@ -73,13 +131,70 @@ func SortByType(iocs []*IOC) []*IOC {
 }
 
 // PrintIOCs Takes IOCs and prints them according to the format desired
-// Format can be csv or table
+// Format can be csv, table, openioc, suricata, snort, yara, sigma, splunk, elastic-kql, elastic-eql, microsoft-kql,
+// hosts, rpz, dnsmasq, unbound, adblock, cidr, iptables, nftables, pf, cisco, zeek, markdown or html
 func PrintIOCs(iocs []*IOC, format string) string {
 	switch format {
 	case "csv":
 		return PrintIOCsCSV(iocs)
 	case "table":
 		return PrintIOCsTable(iocs)
+	case "openioc":
+		document, err := PrintIOCsOpenIOC(iocs, OpenIOCOptions{})
+		if err != nil {
+			return err.Error()
+		}
+		return document
+	case "suricata":
+		// The default options have no sid limit, so this can not fail
+		rules, _ := PrintIOCsSuricata(iocs, RuleOptions{})
+		return rules
+	case "snort":
+		rules, _ := PrintIOCsSnort(iocs, RuleOptions{})
+		return rules
+	case "yara":
+		// Rules are validated, so errors here would be bugs in the generator
+		rules, err := PrintIOCsYARA(iocs, YARAOptions{})
+		if err != nil {
+			return err.Error()
+		}
+		return rules
+	case "sigma":
+		return PrintIOCsSigma(iocs, SigmaOptions{})
+	case "splunk":
+		return PrintIOCsSplunk(iocs)
+	case "elastic-kql":
+		return PrintIOCsElasticKQL(iocs)
+	case "elastic-eql":
+		return PrintIOCsElasticEQL(iocs)
+	case "microsoft-kql":
+		return PrintIOCsMicrosoftKQL(iocs)
+	case "hosts":
+		return PrintIOCsHosts(iocs, BlocklistOptions{})
+	case "rpz":
+		return PrintIOCsRPZ(iocs, BlocklistOptions{})
+	case "dnsmasq":
+		return PrintIOCsDnsmasq(iocs, BlocklistOptions{})
+	case "unbound":
+		return PrintIOCsUnbound(iocs, BlocklistOptions{})
+	case "adblock":
+		return PrintIOCsAdblock(iocs, BlocklistOptions{})
+	case "cidr":
+		return PrintIOCsCIDR(iocs)
+	case "iptables":
+		return PrintIOCsIptables(iocs, FirewallOptions{})
+	case "nftables":
+		return PrintIOCsNftables(iocs, FirewallOptions{})
+	case "pf":
+		return PrintIOCsPf(iocs, FirewallOptions{})
+	case "cisco":
+		return PrintIOCsCiscoACL(iocs, FirewallOptions{})
+	case "zeek":
+		return PrintIOCsZeek(iocs, ZeekOptions{})
+	case "markdown":
+		return PrintIOCsMarkdown(iocs, ReportOptions{})
+	case "html":
+		return PrintIOCsHTML(iocs, ReportOptions{})
 	default:
 		return PrintIOCsCSV(iocs)
 	}
@@ -121,13 +236,16 @@ func PrintIOCsTable(iocs []*IOC) string {
 	return ret.String()
 }
 
-// PrintIOCsStats Given iocs print the stats associated with them
+// PrintIOCsStats Given iocs print the count of each type, in the order of Types
 func PrintIOCsStats(iocs []*IOC) string {
 	stats := GetIOCsCounts(iocs)
 
 	ret := ""
-	for iocType, count := range stats {
-		ret += fmt.Sprintf("%s: %d\n", iocType.String(), count)
+	for _, iocType := range Types {
+		if stats[iocType] == 0 {
+			continue
+		}
+		ret += fmt.Sprintf("%s: %d\n", iocType.String(), stats[iocType])
 	}
 
 	return ret
@@ -0,0 +1,131 @@
+package ioc
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// URLComponents The parts of a URL IOC, see ParseURL
+type URLComponents struct {
+	Scheme string
+	User   string
+	Host   string
+	// HostType Domain, IPv4, or IPv6, Unknown if the host is none of them
+	HostType Type
+	Port     string
+	// Path The decoded path
+	Path string
+	// File The last segment of the path if it has an extension, ex: invoice.pdf, and its lowercase Extension, ex: pdf
+	File      string
+	Extension string
+	// Query The decoded query parameters
+	Query    url.Values
+	Fragment string
+	// Redirects The URLs in the query parameters and fragment, ex: the value of ?url=http%3A%2F%2Fevil.com
+	Redirects []string
+}
+
+// ParseURL Split a URL IOC, after fanging it, in to its components.  Errors if the IOC is not a URL or can not be parsed.
+func (ioc *IOC) ParseURL() (*URLComponents, error) {
+	if ioc.Type != URL {
+		return nil, fmt.Errorf("%s is a %s, not a URL", ioc.IOC, ioc.Type)
+	}
+	parsed, err := url.Parse(ioc.Fang().IOC)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &URLComponents{
+		Scheme:   strings.ToLower(parsed.Scheme),
+		Host:     parsed.Hostname(),
+		HostType: Unknown,
+		Port:     parsed.Port(),
+		Path:     parsed.Path,
+		Query:    parsed.Query(),
+		Fragment: parsed.Fragment,
+	}
+	if parsed.User != nil {
+		ret.User = parsed.User.Username()
+	}
+	if ip := net.ParseIP(ret.Host); ip != nil {
+		ret.HostType = ipType(ip)
+	} else if host := ParseIOC(ret.Host); host.Type == Domain && host.IOC == ret.Host {
+		ret.HostType = Domain
+	}
+	if base := path.Base(parsed.Path); path.Ext(base) != "" && !strings.HasSuffix(parsed.Path, "/") {
+		ret.File = base
+		ret.Extension = strings.ToLower(strings.TrimPrefix(path.Ext(base), "."))
+	}
+
+	// Parameters in a stable order, since the query is a map
+	keys := []string{}
+	for key := range ret.Query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := []string{}
+	for _, key := range keys {
+		values = append(values, ret.Query[key]...)
+	}
+	seen := map[string]bool{}
+	for _, value := range append(values, ret.Fragment) {
+		for _, redirect := range iocRegexes[URL].FindAllString(value, -1) {
+			if redirect = (&IOC{IOC: redirect, Type: URL}).Fang().IOC; !seen[redirect] {
+				seen[redirect] = true
+				ret.Redirects = append(ret.Redirects, redirect)
+			}
+		}
+	}
+	return ret, nil
+}
+
+// ExpandRedirects Add the URLs that URL IOCs redirect to in their query parameters (see ParseURL), and their domains or IPs,
+// after each URL.  Each has the metadata of the URL it was in, and the URL as its "redirect_from" Metadata,
+// and is defanged if the URL was.  Redirects in the redirects are added too.
+func ExpandRedirects(iocs []*FoundIOC) []*FoundIOC {
+	ret := []*FoundIOC{}
+	for _, ioc := range iocs {
+		ret = appendRedirects(append(ret, ioc), ioc)
+	}
+	return ret
+}
+
+// appendRedirects Add the IOCs in the redirects of a URL IOC, and their redirects
+func appendRedirects(iocs []*FoundIOC, ioc *FoundIOC) []*FoundIOC {
+	components, err := ioc.ToIOC().ParseURL()
+	if err != nil {
+		return iocs
+	}
+
+	defanged := !ioc.IsFanged()
+	for _, redirect := range components.Redirects {
+		// The URL, then its host
+		targets := []*IOC{{IOC: redirect, Type: URL}}
+		if redirectComponents, err := targets[0].ParseURL(); err == nil && redirectComponents.HostType != Unknown {
+			targets = append(targets, &IOC{IOC: redirectComponents.Host, Type: redirectComponents.HostType})
+		}
+
+		added := []*FoundIOC{}
+		for _, redirect := range targets {
+			if defanged {
+				redirect = redirect.Defang()
+			}
+			target := ioc.with(redirect)
+			setMetadata(target, "redirect_from", ioc.IOC)
+			if !containsFoundIOCInSource(iocs, target) {
+				iocs = append(iocs, target)
+				added = append(added, target)
+			}
+		}
+		for _, target := range added {
+			if target.Type == URL {
+				iocs = appendRedirects(iocs, target)
+			}
+		}
+	}
+	return iocs
+}
@@ -0,0 +1,90 @@
+package ioc
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		ioc  string
+		want *URLComponents
+	}{
+		{
+			"hxxps://admin@Login.evil[.]com:8443/a/b/Invoice.PDF?u=http%3A%2F%2Fphish.org%2Fx&q=a+b#frag",
+			&URLComponents{
+				Scheme: "https", User: "admin", Host: "Login.evil.com", HostType: Domain, Port: "8443",
+				Path: "/a/b/Invoice.PDF", File: "Invoice.PDF", Extension: "pdf",
+				Query:     url.Values{"u": {"http://phish.org/x"}, "q": {"a b"}},
+				Fragment:  "frag",
+				Redirects: []string{"http://phish.org/x"},
+			},
+		},
+		{
+			"http://1.2.3.4/dir.d/",
+			&URLComponents{Scheme: "http", Host: "1.2.3.4", HostType: IPv4, Path: "/dir.d/", Query: url.Values{}},
+		},
+		{
+			"http://[2001:db8::1]:80/#hxxp://next[.]com",
+			&URLComponents{Scheme: "http", Host: "2001:db8::1", HostType: IPv6, Port: "80", Path: "/", Query: url.Values{},
+				Fragment: "http://next.com", Redirects: []string{"http://next.com"}},
+		},
+	}
+	for _, test := range tests {
+		got, err := (&IOC{IOC: test.ioc, Type: URL}).ParseURL()
+		if err != nil {
+			t.Errorf("%s: %s", test.ioc, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, wanted %+v", test.ioc, got, test.want)
+		}
+	}
+
+	if _, err := (&IOC{IOC: "evil.com", Type: Domain}).ParseURL(); err == nil {
+		t.Errorf("Should have errored on a domain")
+	}
+}
+
+func TestExpandRedirects(t *testing.T) {
+	outer := "hxxps://evil[.]com/r?url=http%3A%2F%2Fphish.org%2Fx%3Fnext%3Dhttp%253A%252F%252F93.184.216.34%252Fz"
+	iocs := []*FoundIOC{
+		{IOC: outer, Type: URL, Source: "a.txt", Metadata: map[string]string{"field": "msg"}},
+		{IOC: "evil[.]com", Type: Domain},
+	}
+	middle := "hxxp[://]phish[.]org/x?next=hxxp%3A%2F%2F93[.]184[.]216[.]34%2Fz"
+	metadata := func(from string) map[string]string {
+		return map[string]string{"field": "msg", "redirect_from": from}
+	}
+	want := []*FoundIOC{
+		iocs[0],
+		{IOC: middle, Type: URL, Source: "a.txt", Metadata: metadata(outer)},
+		{IOC: "phish[.]org", Type: Domain, Source: "a.txt", Metadata: metadata(outer)},
+		{IOC: "hxxp[://]93[.]184[.]216[.]34/z", Type: URL, Source: "a.txt", Metadata: metadata(middle)},
+		{IOC: "93[.]184[.]216[.]34", Type: IPv4, Source: "a.txt", Metadata: metadata(middle)},
+		iocs[1],
+	}
+	if got := ExpandRedirects(iocs); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+		for _, ioc := range got {
+			t.Log(ioc.IOC, ioc.Type, ioc.Source, ioc.Metadata)
+		}
+	}
+
+	// The same redirect in another source is kept
+	iocs = []*FoundIOC{
+		{IOC: "hxxp://a[.]com/?u=http://phish.org/", Type: URL, Source: "a.txt"},
+		{IOC: "hxxp://b[.]com/?u=http://phish.org/", Type: URL, Source: "b.txt"},
+		{IOC: "hxxp://c[.]com/?u=http://phish.org/", Type: URL, Source: "b.txt"},
+	}
+	sources := []string{}
+	for _, ioc := range ExpandRedirects(iocs) {
+		if ioc.Type == Domain {
+			sources = append(sources, ioc.Source)
+		}
+	}
+	if want := []string{"a.txt", "b.txt"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("got the redirect host from %v, wanted %v", sources, want)
+	}
+}
@@ -0,0 +1,140 @@
+package ioc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// YARAOptions Options used when generating YARA rules
+type YARAOptions struct {
+	// Source Title or URL of the report the IOCs came from, used to name the rules
+	Source      string
+	Author      string
+	Description string
+	Reference   string
+	Date        time.Time // Defaults to now
+}
+
+// yaraStringRules The rules made of string matches, and the types that go in each
+var yaraStringRules = []struct {
+	suffix string
+	types  []Type
+}{
+	{"domains", []Type{Domain}},
+	{"urls", []Type{URL}},
+	{"wallets", []Type{Bitcoin}},
+}
+
+var yaraIdentifierReplace = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// PrintIOCsYARA Takes []IOC and returns YARA rules matching them.
+// File hashes are matched using the hash module, and domains, URLs, and wallet addresses are matched as strings.
+// The produced rules are checked with ValidateYARA so broken rules are never returned.
+func PrintIOCsYARA(iocs []*IOC, options YARAOptions) (string, error) {
+	if options.Date.IsZero() {
+		options.Date = time.Now()
+	}
+	name := yaraRuleName(options.Source)
+
+	values := fangedValuesByType(iocs)
+
+	rules := []string{}
+
+	// Hashes
+	conditions := []string{}
+	for _, hash := range []struct {
+		t    Type
+		name string
+	}{{MD5, "md5"}, {SHA1, "sha1"}, {SHA256, "sha256"}} {
+		for _, value := range values[hash.t] {
+			conditions = append(conditions, fmt.Sprintf(`hash.%s(0, filesize) == "%s"`, hash.name, strings.ToLower(value)))
+		}
+	}
+	if len(conditions) > 0 {
+		rules = append(rules, yaraRule(name+"_hashes", options, nil, strings.Join(conditions, " or\n\t\t")))
+	}
+
+	// Strings
+	for _, stringRule := range yaraStringRules {
+		strs := []string{}
+		for _, t := range stringRule.types {
+			for _, value := range values[t] {
+				strs = append(strs, fmt.Sprintf(`$s%d = "%s" ascii wide nocase`, len(strs), yaraEscape(value)))
+			}
+		}
+		if len(strs) > 0 {
+			rules = append(rules, yaraRule(name+"_"+stringRule.suffix, options, strs, "any of them"))
+		}
+	}
+
+	if len(rules) == 0 {
+		return "", nil
+	}
+	ret := strings.Join(rules, "\n\n")
+	if len(conditions) > 0 {
+		ret = "import \"hash\"\n\n" + ret
+	}
+
+	if err := ValidateYARA(ret); err != nil {
+		return "", fmt.Errorf("generated invalid yara: %s", err)
+	}
+
+	return ret, nil
+}
+
+// yaraRule Build a single rule
+func yaraRule(name string, options YARAOptions, strs []string, condition string) string {
+	ret := "rule " + name + " {\n\tmeta:\n"
+	for _, meta := range [][2]string{
+		{"author", options.Author},
+		{"description", options.Description},
+		{"reference", options.Reference},
+		{"date", options.Date.Format("2006-01-02")},
+	} {
+		if meta[1] != "" {
+			ret += fmt.Sprintf("\t\t%s = \"%s\"\n", meta[0], yaraEscape(meta[1]))
+		}
+	}
+	if len(strs) > 0 {
+		ret += "\tstrings:\n\t\t" + strings.Join(strs, "\n\t\t") + "\n"
+	}
+	ret += "\tcondition:\n\t\t" + condition + "\n}"
+	return ret
+}
+
+// yaraRuleName Derive a valid rule identifier from the source title or URL
+func yaraRuleName(source string) string {
+	if i := strings.Index(source, "://"); i != -1 {
+		source = source[i+3:]
+	}
+	name := strings.Trim(yaraIdentifierReplace.ReplaceAllString(source, "_"), "_")
+	if name == "" {
+		return "go_ioc"
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "ioc_" + name
+	}
+	// Leave room for the suffixes, identifiers can be at most 128 characters
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	return name
+}
+
+// yaraEscape Escape a value to be used in a YARA text string
+func yaraEscape(s string) string {
+	ret := ""
+	for _, c := range []byte(s) {
+		switch {
+		case c == '"' || c == '\\':
+			ret += `\` + string(c)
+		case c < 0x20 || c > 0x7e:
+			ret += fmt.Sprintf(`\x%02x`, c)
+		default:
+			ret += string(c)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,837 @@
+package ioc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// -- YARA grammar check --
+// This is a small recursive descent parser for the YARA rule language.
+// It does not evaluate anything, it only checks the syntax and the identifiers used so we never ship a broken rule file.
+
+type yaraTokenKind int
+
+const (
+	yaraEOF yaraTokenKind = iota
+	yaraIdentifier
+	yaraKeyword
+	yaraStringID    // $a, $a*, $
+	yaraStringCount // #a
+	yaraStringOff   // @a
+	yaraStringLen   // !a
+	yaraNumber
+	yaraText  // "text"
+	yaraHex   // { AA BB }
+	yaraRegex // /regex/
+	yaraPunct
+)
+
+type yaraToken struct {
+	kind  yaraTokenKind
+	value string
+	line  int
+}
+
+var yaraKeywords = map[string]bool{
+	"all": true, "and": true, "any": true, "ascii": true, "at": true, "base64": true, "base64wide": true,
+	"condition": true, "contains": true, "endswith": true, "entrypoint": true, "false": true, "filesize": true,
+	"for": true, "fullword": true, "global": true, "import": true, "icontains": true, "iendswith": true,
+	"iequals": true, "in": true, "include": true, "istartswith": true, "matches": true, "meta": true,
+	"nocase": true, "none": true, "not": true, "of": true, "or": true, "private": true, "rule": true,
+	"startswith": true, "strings": true, "them": true, "true": true, "wide": true, "xor": true,
+}
+
+// yaraPuncts Punctuation, longest first so we match greedily
+var yaraPuncts = []string{"..", "==", "!=", "<=", ">=", "<<", ">>", "(", ")", "[", "]", "{", "}", "=", "<", ">", ",", ":", ".", "+", "-", "*", `\`, "%", "&", "|", "^", "~"}
+
+// yaraLex Split YARA source into tokens
+func yaraLex(src string) ([]yaraToken, error) {
+	tokens := []yaraToken{}
+	line := 1
+	i := 0
+
+	last := func() yaraToken {
+		if len(tokens) == 0 {
+			return yaraToken{}
+		}
+		return tokens[len(tokens)-1]
+	}
+	isIdentChar := func(c byte) bool {
+		return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+	}
+
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"':
+			j := i + 1
+			for ; j < len(src) && src[j] != '"'; j++ {
+				if src[j] == '\n' {
+					return nil, fmt.Errorf("line %d: unterminated string", line)
+				}
+				if src[j] == '\\' {
+					j++
+					if j >= len(src) {
+						break
+					}
+					switch src[j] {
+					case '"', '\\', 'n', 't', 'r':
+					case 'x':
+						if j+2 >= len(src) || !isHexDigit(src[j+1]) || !isHexDigit(src[j+2]) {
+							return nil, fmt.Errorf("line %d: invalid \\x escape in string", line)
+						}
+						j += 2
+					default:
+						return nil, fmt.Errorf("line %d: invalid escape sequence \\%c", line, src[j])
+					}
+				}
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			tokens = append(tokens, yaraToken{yaraText, src[i+1 : j], line})
+			i = j + 1
+		case c == '{' && last().value == "=":
+			end := strings.IndexByte(src[i:], '}')
+			if end == -1 {
+				return nil, fmt.Errorf("line %d: unterminated hex string", line)
+			}
+			tokens = append(tokens, yaraToken{yaraHex, src[i+1 : i+end], line})
+			line += strings.Count(src[i:i+end], "\n")
+			i += end + 1
+		case c == '/' && (last().value == "=" || last().value == "matches" || last().value == "(" || last().value == ","):
+			j := i + 1
+			for ; j < len(src) && src[j] != '/'; j++ {
+				if src[j] == '\\' {
+					j++
+				}
+				if j < len(src) && src[j] == '\n' {
+					return nil, fmt.Errorf("line %d: unterminated regular expression", line)
+				}
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("line %d: unterminated regular expression", line)
+			}
+			if j == i+1 {
+				return nil, fmt.Errorf("line %d: empty regular expression", line)
+			}
+			// Flags
+			for j+1 < len(src) && (src[j+1] == 'i' || src[j+1] == 's') {
+				j++
+			}
+			tokens = append(tokens, yaraToken{yaraRegex, src[i : j+1], line})
+			i = j + 1
+		case c == '$' || c == '#' || c == '@' || c == '!' && i+1 < len(src) && isIdentChar(src[i+1]):
+			j := i + 1
+			for j < len(src) && isIdentChar(src[j]) {
+				j++
+			}
+			kind := map[byte]yaraTokenKind{'$': yaraStringID, '#': yaraStringCount, '@': yaraStringOff, '!': yaraStringLen}[c]
+			if kind == yaraStringID && j < len(src) && src[j] == '*' {
+				j++
+			}
+			tokens = append(tokens, yaraToken{kind, src[i:j], line})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i + 1
+			if strings.HasPrefix(src[i:], "0x") {
+				j = i + 2
+				for j < len(src) && isHexDigit(src[j]) {
+					j++
+				}
+			} else {
+				for j < len(src) && src[j] >= '0' && src[j] <= '9' {
+					j++
+				}
+				if strings.HasPrefix(src[j:], "KB") || strings.HasPrefix(src[j:], "MB") {
+					j += 2
+				}
+			}
+			if j < len(src) && isIdentChar(src[j]) {
+				return nil, fmt.Errorf("line %d: invalid number %s", line, src[i:j+1])
+			}
+			tokens = append(tokens, yaraToken{yaraNumber, src[i:j], line})
+			i = j
+		case isIdentChar(c):
+			j := i
+			for j < len(src) && isIdentChar(src[j]) {
+				j++
+			}
+			kind := yaraIdentifier
+			if yaraKeywords[src[i:j]] {
+				kind = yaraKeyword
+			}
+			tokens = append(tokens, yaraToken{kind, src[i:j], line})
+			i = j
+		default:
+			matched := false
+			for _, punct := range yaraPuncts {
+				if strings.HasPrefix(src[i:], punct) {
+					tokens = append(tokens, yaraToken{yaraPunct, punct, line})
+					i += len(punct)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+			}
+		}
+	}
+
+	return append(tokens, yaraToken{yaraEOF, "", line}), nil
+}
+
+// yaraParser State while checking a YARA file
+type yaraParser struct {
+	tokens []yaraToken
+	pos    int
+
+	imports map[string]bool
+	rules   map[string]bool
+
+	// Per rule state
+	strs       map[string]bool
+	referenced map[string]bool
+	anonymous  bool // Inside a for .. of loop where $ can be used
+}
+
+// ValidateYARA Check YARA rules for syntax errors, duplicate rules, and undefined or unreferenced identifiers.
+// Returns nil if the rules are valid.
+func ValidateYARA(rules string) error {
+	tokens, err := yaraLex(rules)
+	if err != nil {
+		return err
+	}
+
+	p := &yaraParser{tokens: tokens, imports: map[string]bool{}, rules: map[string]bool{}}
+	for p.peek().kind != yaraEOF {
+		if err := p.parseTopLevel(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *yaraParser) peek() yaraToken {
+	return p.tokens[p.pos]
+}
+
+func (p *yaraParser) next() yaraToken {
+	t := p.tokens[p.pos]
+	if t.kind != yaraEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept Consume the next token if it has this value
+func (p *yaraParser) accept(value string) bool {
+	if t := p.peek(); (t.kind == yaraKeyword || t.kind == yaraPunct) && t.value == value {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *yaraParser) expect(value string) error {
+	if !p.accept(value) {
+		return p.errorf("expected %q", value)
+	}
+	return nil
+}
+
+func (p *yaraParser) errorf(format string, args ...interface{}) error {
+	t := p.peek()
+	found := t.value
+	if t.kind == yaraEOF {
+		found = "end of file"
+	}
+	return fmt.Errorf("line %d: %s, found %q", t.line, fmt.Sprintf(format, args...), found)
+}
+
+func (p *yaraParser) parseTopLevel() error {
+	if p.accept("import") {
+		t := p.next()
+		if t.kind != yaraText {
+			return fmt.Errorf("line %d: import requires a module name string", t.line)
+		}
+		p.imports[t.value] = true
+		return nil
+	}
+	if p.accept("include") {
+		if t := p.next(); t.kind != yaraText {
+			return fmt.Errorf("line %d: include requires a file name string", t.line)
+		}
+		return nil
+	}
+	return p.parseRule()
+}
+
+func (p *yaraParser) parseRule() error {
+	for p.accept("private") || p.accept("global") {
+	}
+	if err := p.expect("rule"); err != nil {
+		return err
+	}
+
+	name := p.next()
+	if name.kind != yaraIdentifier {
+		return fmt.Errorf("line %d: invalid rule name %q", name.line, name.value)
+	}
+	if len(name.value) > 128 {
+		return fmt.Errorf("line %d: rule name %q is longer than 128 characters", name.line, name.value)
+	}
+	if p.rules[name.value] {
+		return fmt.Errorf("line %d: duplicate rule %q", name.line, name.value)
+	}
+
+	// Tags
+	if p.accept(":") {
+		if p.peek().kind != yaraIdentifier {
+			return p.errorf("expected tag")
+		}
+		for p.peek().kind == yaraIdentifier {
+			p.next()
+		}
+	}
+
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+
+	p.strs = map[string]bool{}
+	p.referenced = map[string]bool{}
+
+	if p.accept("meta") {
+		if err := p.parseMeta(); err != nil {
+			return err
+		}
+	}
+	if p.accept("strings") {
+		if err := p.parseStrings(); err != nil {
+			return err
+		}
+	}
+	if err := p.expect("condition"); err != nil {
+		return err
+	}
+	if err := p.expect(":"); err != nil {
+		return err
+	}
+	if err := p.parseExpression(); err != nil {
+		return err
+	}
+	if err := p.expect("}"); err != nil {
+		return err
+	}
+
+	for id := range p.strs {
+		if !p.referenced[id] {
+			return fmt.Errorf("line %d: unreferenced string %q in rule %q", name.line, id, name.value)
+		}
+	}
+
+	// Only define the rule afterwards, rules can not reference themselves
+	p.rules[name.value] = true
+	return nil
+}
+
+func (p *yaraParser) parseMeta() error {
+	if err := p.expect(":"); err != nil {
+		return err
+	}
+	for p.peek().kind == yaraIdentifier {
+		p.next()
+		if err := p.expect("="); err != nil {
+			return err
+		}
+		p.accept("-")
+		t := p.next()
+		if t.kind != yaraText && t.kind != yaraNumber && t.value != "true" && t.value != "false" {
+			return fmt.Errorf("line %d: invalid meta value %q", t.line, t.value)
+		}
+	}
+	return nil
+}
+
+func (p *yaraParser) parseStrings() error {
+	if err := p.expect(":"); err != nil {
+		return err
+	}
+	if p.peek().kind != yaraStringID {
+		return p.errorf("expected string identifier")
+	}
+	for p.peek().kind == yaraStringID {
+		id := p.next()
+		if id.value == "$" || strings.HasSuffix(id.value, "*") {
+			return fmt.Errorf("line %d: invalid string identifier %q", id.line, id.value)
+		}
+		if p.strs[id.value] {
+			return fmt.Errorf("line %d: duplicate string identifier %q", id.line, id.value)
+		}
+		p.strs[id.value] = true
+
+		if err := p.expect("="); err != nil {
+			return err
+		}
+
+		value := p.next()
+		switch value.kind {
+		case yaraText:
+			if value.value == "" {
+				return fmt.Errorf("line %d: empty string %q", value.line, id.value)
+			}
+		case yaraHex:
+			if err := validateYARAHex(value.value); err != nil {
+				return fmt.Errorf("line %d: %s", value.line, err)
+			}
+		case yaraRegex:
+		default:
+			return fmt.Errorf("line %d: invalid value for string %q", value.line, id.value)
+		}
+
+		// Modifiers
+	modifiers:
+		for {
+			switch t := p.peek(); t.value {
+			case "nocase", "ascii", "wide", "fullword", "private", "base64wide":
+				p.next()
+			case "xor":
+				p.next()
+				if p.accept("(") {
+					if err := p.expectNumber(); err != nil {
+						return err
+					}
+					if p.accept("-") {
+						if err := p.expectNumber(); err != nil {
+							return err
+						}
+					}
+					if err := p.expect(")"); err != nil {
+						return err
+					}
+				}
+			case "base64":
+				p.next()
+				if p.accept("(") {
+					if t := p.next(); t.kind != yaraText {
+						return fmt.Errorf("line %d: base64 alphabet must be a string", t.line)
+					}
+					if err := p.expect(")"); err != nil {
+						return err
+					}
+				}
+			default:
+				if t.kind == yaraKeyword && t.value != "condition" {
+					return fmt.Errorf("line %d: invalid string modifier %q", t.line, t.value)
+				}
+				break modifiers
+			}
+		}
+	}
+	return nil
+}
+
+func (p *yaraParser) expectNumber() error {
+	if p.peek().kind != yaraNumber {
+		return p.errorf("expected number")
+	}
+	p.next()
+	return nil
+}
+
+// validateYARAHex Check the contents of a hex string
+func validateYARAHex(hex string) error {
+	fields := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ", "|", " | ", "[", " [", "]", "] ").Replace(hex))
+	bytes := 0
+	depth := 0
+	for _, field := range fields {
+		switch {
+		case field == "(":
+			depth++
+		case field == ")":
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("unbalanced parentheses in hex string")
+			}
+		case field == "|":
+			if depth == 0 {
+				return fmt.Errorf("alternatives in hex strings must be in parentheses")
+			}
+		case strings.HasPrefix(field, "["):
+			if !strings.HasSuffix(field, "]") {
+				return fmt.Errorf("invalid jump %q in hex string", field)
+			}
+			for _, c := range field[1 : len(field)-1] {
+				if !(c >= '0' && c <= '9') && c != '-' {
+					return fmt.Errorf("invalid jump %q in hex string", field)
+				}
+			}
+		default:
+			if len(field)%2 != 0 {
+				return fmt.Errorf("hex string has an odd number of digits")
+			}
+			for i := 0; i < len(field); i++ {
+				if !isHexDigit(field[i]) && field[i] != '?' && field[i] != '~' {
+					return fmt.Errorf("invalid character %q in hex string", field[i])
+				}
+			}
+			bytes += len(field) / 2
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("unbalanced parentheses in hex string")
+	}
+	if bytes == 0 {
+		return fmt.Errorf("empty hex string")
+	}
+	return nil
+}
+
+// -- Conditions --
+
+func (p *yaraParser) parseExpression() error {
+	if err := p.parseAnd(); err != nil {
+		return err
+	}
+	for p.accept("or") {
+		if err := p.parseAnd(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *yaraParser) parseAnd() error {
+	if err := p.parseNot(); err != nil {
+		return err
+	}
+	for p.accept("and") {
+		if err := p.parseNot(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *yaraParser) parseNot() error {
+	if p.accept("not") {
+		return p.parseNot()
+	}
+	return p.parseRelational()
+}
+
+var yaraRelationalOperators = []string{"==", "!=", "<", "<=", ">", ">=", "contains", "icontains", "startswith", "istartswith", "endswith", "iendswith", "iequals"}
+
+func (p *yaraParser) parseRelational() error {
+	if err := p.parseArithmetic(); err != nil {
+		return err
+	}
+	for {
+		if p.accept("matches") {
+			if t := p.next(); t.kind != yaraRegex {
+				return fmt.Errorf("line %d: matches requires a regular expression", t.line)
+			}
+			continue
+		}
+		matched := false
+		for _, op := range yaraRelationalOperators {
+			if p.accept(op) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil
+		}
+		if err := p.parseArithmetic(); err != nil {
+			return err
+		}
+	}
+}
+
+var yaraArithmeticOperators = []string{"+", "-", "*", `\`, "%", "&", "|", "^", "<<", ">>"}
+
+func (p *yaraParser) parseArithmetic() error {
+	if err := p.parseUnary(); err != nil {
+		return err
+	}
+	for {
+		matched := false
+		for _, op := range yaraArithmeticOperators {
+			if p.accept(op) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil
+		}
+		if err := p.parseUnary(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *yaraParser) parseUnary() error {
+	if p.accept("-") || p.accept("~") {
+		return p.parseUnary()
+	}
+	return p.parsePrimary()
+}
+
+func (p *yaraParser) parsePrimary() error {
+	t := p.peek()
+	switch {
+	case p.accept("("):
+		if err := p.parseExpression(); err != nil {
+			return err
+		}
+		return p.expect(")")
+	case t.value == "for":
+		return p.parseFor()
+	case t.value == "any" || t.value == "all" || t.value == "none":
+		p.next()
+		return p.parseOf()
+	case t.kind == yaraNumber:
+		p.next()
+		if p.peek().value == "of" {
+			return p.parseOf()
+		}
+		return nil
+	case t.kind == yaraText || t.value == "true" || t.value == "false" || t.value == "filesize" || t.value == "entrypoint":
+		p.next()
+		return nil
+	case t.kind == yaraStringID:
+		p.next()
+		if err := p.reference(t); err != nil {
+			return err
+		}
+		if p.accept("at") {
+			return p.parseArithmetic()
+		}
+		if p.accept("in") {
+			return p.parseRange()
+		}
+		return nil
+	case t.kind == yaraStringCount:
+		p.next()
+		if err := p.reference(t); err != nil {
+			return err
+		}
+		if p.accept("in") {
+			return p.parseRange()
+		}
+		return nil
+	case t.kind == yaraStringOff || t.kind == yaraStringLen:
+		p.next()
+		if err := p.reference(t); err != nil {
+			return err
+		}
+		if p.accept("[") {
+			if err := p.parseExpression(); err != nil {
+				return err
+			}
+			return p.expect("]")
+		}
+		return nil
+	case t.kind == yaraIdentifier:
+		return p.parseIdentifier()
+	}
+	return p.errorf("expected expression")
+}
+
+// reference Mark a string as referenced, making sure it exists
+func (p *yaraParser) reference(t yaraToken) error {
+	id := "$" + t.value[1:]
+	if id == "$" {
+		if !p.anonymous {
+			return fmt.Errorf("line %d: anonymous string used outside of a for loop", t.line)
+		}
+		return nil
+	}
+	if !p.strs[id] {
+		return fmt.Errorf("line %d: undefined string %q", t.line, id)
+	}
+	p.referenced[id] = true
+	return nil
+}
+
+// parseOf Parse the `of` after a quantifier
+func (p *yaraParser) parseOf() error {
+	if err := p.expect("of"); err != nil {
+		return err
+	}
+	if err := p.parseStringSet(); err != nil {
+		return err
+	}
+	if p.accept("in") {
+		return p.parseRange()
+	}
+	return nil
+}
+
+// parseStringSet Parse `them` or a list of strings like ($a, $b*)
+func (p *yaraParser) parseStringSet() error {
+	if p.accept("them") {
+		if len(p.strs) == 0 {
+			return fmt.Errorf("line %d: them used in a rule with no strings", p.tokens[p.pos-1].line)
+		}
+		for id := range p.strs {
+			p.referenced[id] = true
+		}
+		return nil
+	}
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for {
+		if p.peek().kind != yaraStringID {
+			return p.errorf("expected string identifier")
+		}
+		t := p.next()
+		if strings.HasSuffix(t.value, "*") {
+			prefix := strings.TrimSuffix(t.value, "*")
+			found := false
+			for id := range p.strs {
+				if strings.HasPrefix(id, prefix) {
+					p.referenced[id] = true
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("line %d: no strings match %q", t.line, t.value)
+			}
+		} else if err := p.reference(t); err != nil {
+			return err
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	return p.expect(")")
+}
+
+// parseRange Parse a range like (0..filesize)
+func (p *yaraParser) parseRange() error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	if err := p.parseArithmetic(); err != nil {
+		return err
+	}
+	if err := p.expect(".."); err != nil {
+		return err
+	}
+	if err := p.parseArithmetic(); err != nil {
+		return err
+	}
+	return p.expect(")")
+}
+
+// parseFor Parse `for quantifier of set : (expression)` or `for quantifier var in range : (expression)`
+func (p *yaraParser) parseFor() error {
+	p.next()
+	if !p.accept("any") && !p.accept("all") && !p.accept("none") {
+		if err := p.parseArithmetic(); err != nil {
+			return err
+		}
+	}
+
+	wasAnonymous := p.anonymous
+	defer func() { p.anonymous = wasAnonymous }()
+
+	if p.accept("of") {
+		if err := p.parseStringSet(); err != nil {
+			return err
+		}
+		p.anonymous = true
+	} else {
+		if p.peek().kind != yaraIdentifier {
+			return p.errorf("expected loop variable")
+		}
+		variable := p.next().value
+		if err := p.expect("in"); err != nil {
+			return err
+		}
+		if err := p.parseRange(); err != nil {
+			return err
+		}
+		// Treat the loop variable like a module so it can be referenced
+		hadVariable := p.imports[variable]
+		p.imports[variable] = true
+		defer func() { p.imports[variable] = hadVariable }()
+	}
+
+	if err := p.expect(":"); err != nil {
+		return err
+	}
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	if err := p.parseExpression(); err != nil {
+		return err
+	}
+	return p.expect(")")
+}
+
+// parseIdentifier Parse a rule reference or a module member like hash.md5(0, filesize)
+func (p *yaraParser) parseIdentifier() error {
+	t := p.next()
+	if !p.imports[t.value] && !p.rules[t.value] {
+		return fmt.Errorf("line %d: undefined identifier %q", t.line, t.value)
+	}
+
+	for {
+		switch {
+		case p.accept("."):
+			if p.peek().kind != yaraIdentifier {
+				return p.errorf("expected identifier")
+			}
+			p.next()
+		case p.accept("["):
+			if err := p.parseExpression(); err != nil {
+				return err
+			}
+			if err := p.expect("]"); err != nil {
+				return err
+			}
+		case p.accept("("):
+			if p.accept(")") {
+				continue
+			}
+			for {
+				if p.peek().kind == yaraRegex {
+					p.next()
+				} else if err := p.parseExpression(); err != nil {
+					return err
+				}
+				if !p.accept(",") {
+					break
+				}
+			}
+			if err := p.expect(")"); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package ioc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrintIOCsYARA(t *testing.T) {
+	iocs := []*IOC{
+		{"874058E8D8582BF85C115CE319C5B0AF", MD5},
+		{"751641b4e4e6cc30f497639eee583b5b392451fb", SHA1},
+		{"example[.]com", Domain},
+		{"example(.)com", Domain},
+		{"hxxp[://]example[.]com/a\"b", URL},
+		{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Bitcoin},
+		{"1.2.3.4", IPv4},
+	}
+	got, err := PrintIOCsYARA(iocs, YARAOptions{
+		Source: "https://example.com/2020/bad-report",
+		Author: "tester",
+		Date:   time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `import "hash"
+
+rule example_com_2020_bad_report_hashes {
+	meta:
+		author = "tester"
+		date = "2020-03-01"
+	condition:
+		hash.md5(0, filesize) == "874058e8d8582bf85c115ce319c5b0af" or
+		hash.sha1(0, filesize) == "751641b4e4e6cc30f497639eee583b5b392451fb"
+}
+
+rule example_com_2020_bad_report_domains {
+	meta:
+		author = "tester"
+		date = "2020-03-01"
+	strings:
+		$s0 = "example.com" ascii wide nocase
+	condition:
+		any of them
+}
+
+rule example_com_2020_bad_report_urls {
+	meta:
+		author = "tester"
+		date = "2020-03-01"
+	strings:
+		$s0 = "http://example.com/a\"b" ascii wide nocase
+	condition:
+		any of them
+}
+
+rule example_com_2020_bad_report_wallets {
+	meta:
+		author = "tester"
+		date = "2020-03-01"
+	strings:
+		$s0 = "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2" ascii wide nocase
+	condition:
+		any of them
+}`
+	if got != want {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, want)
+	}
+
+	// Nothing to export
+	if got, err := PrintIOCsYARA([]*IOC{{"1.2.3.4", IPv4}}, YARAOptions{}); got != "" || err != nil {
+		t.Errorf("Expected no rules, got %q %v", got, err)
+	}
+}
+
+func TestYARARuleName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", "go_ioc"},
+		{"APT29 targets COVID-19 vaccine research!", "APT29_targets_COVID_19_vaccine_research"},
+		{"https://www.example.com/blog/post", "www_example_com_blog_post"},
+		{"2020 report", "ioc_2020_report"},
+		{strings.Repeat("a", 200), strings.Repeat("a", 100)},
+	}
+	for _, test := range tests {
+		if got := yaraRuleName(test.input); got != test.want {
+			t.Errorf("yaraRuleName(%q) = %q, wanted %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestValidateYARA(t *testing.T) {
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{`rule a { condition: true }`, true},
+		{`rule a : tag1 tag2 { meta: x = "y" n = -1 b = true strings: $a = "x" nocase wide $b = { AA ?? [1-2] (BB | CC) } $c = /ab+c/i condition: $a and #b > 1 and @c[1] < 100 }`, true},
+		{`rule a { strings: $a1 = "x" $a2 = "y" condition: any of ($a*) }`, true},
+		{`rule a { strings: $a = "x" xor(1-255) condition: for any of them : ( $ at 0 ) }`, true},
+		{`import "hash" rule a { condition: hash.md5(0, filesize) == "abc" }`, true},
+		{`import "pe" rule a { condition: pe.number_of_sections > 1 and pe.sections[0].name == ".text" and pe.exports(/foo/) }`, true},
+		{`rule a { condition: true } rule b { condition: a and filesize < 10KB }`, true},
+		{`rule a { condition: for all i in (1..3) : ( i > 0 ) }`, true},
+		{`/* comment */ rule a { // comment
+			condition: true }`, true},
+
+		// Invalid
+		{`rule a { condition: }`, false},
+		{`rule a { condition: true`, false},
+		{`rule { condition: true }`, false},
+		{`rule condition { condition: true }`, false},
+		{`rule a { condition: true } rule a { condition: true }`, false},
+		{`rule a { condition: hash.md5(0, filesize) == "abc" }`, false},
+		{`rule a { condition: b }`, false},
+		{`rule a { strings: $a = "x" condition: true }`, false},
+		{`rule a { strings: $a = "x" condition: $b }`, false},
+		{`rule a { strings: $a = "x" $a = "y" condition: all of them }`, false},
+		{`rule a { strings: $a = "" condition: $a }`, false},
+		{`rule a { strings: $a = "\q" condition: $a }`, false},
+		{`rule a { strings: $a = "x condition: $a }`, false},
+		{`rule a { strings: $a = { AAB } condition: $a }`, false},
+		{`rule a { strings: $a = "x" bogus condition: $a }`, false},
+		{`rule a { condition: any of them }`, false},
+		{`rule a { condition: $ }`, false},
+		{`rule a { condition: (true }`, false},
+	}
+	for _, test := range tests {
+		err := ValidateYARA(test.input)
+		if (err == nil) != test.valid {
+			t.Errorf("ValidateYARA(%q) = %v, wanted valid=%v", test.input, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package ioc
+
+import (
+	"strings"
+)
+
+// ZeekOptions Metadata to include with each Zeek intel item
+type ZeekOptions struct {
+	Source      string // meta.source, defaults to go-ioc
+	Description string // meta.desc
+	URL         string // meta.url, typically the article the IOCs came from
+}
+
+// zeekIndicatorTypes Zeek Intel Framework types for each IOC type.
+// Types not in this map can not be matched by Zeek and are skipped.
+var zeekIndicatorTypes = map[Type]string{
+	MD5:    "Intel::FILE_HASH",
+	SHA1:   "Intel::FILE_HASH",
+	SHA256: "Intel::FILE_HASH",
+	SHA512: "Intel::FILE_HASH",
+	Domain: "Intel::DOMAIN",
+	Email:  "Intel::EMAIL",
+	IPv4:   "Intel::ADDR",
+	IPv6:   "Intel::ADDR",
+	URL:    "Intel::URL",
+	File:   "Intel::FILE_NAME",
+}
+
+// PrintIOCsZeek Takes []IOC and returns a Zeek Intelligence Framework file that can be loaded with Intel::read_files.
+// IOCs are fanged, hashes lowercased, and URLs have their scheme stripped since that is how Zeek sees them.
+// Each indicator is only included once for its type.
+func PrintIOCsZeek(iocs []*IOC, options ZeekOptions) string {
+	if options.Source == "" {
+		options.Source = "go-ioc"
+	}
+
+	ret := []string{"#fields\tindicator\tindicator_type\tmeta.source\tmeta.desc\tmeta.url"}
+	seen := map[[2]string]bool{}
+	for _, ioc := range iocs {
+		indicatorType, ok := zeekIndicatorTypes[ioc.Type]
+		if !ok {
+			continue
+		}
+
+		indicator := ioc.Fang().IOC
+		switch ioc.Type {
+		case URL:
+			if i := strings.Index(indicator, "://"); i != -1 {
+				indicator = indicator[i+3:]
+			}
+		case MD5, SHA1, SHA256, SHA512:
+			indicator = strings.ToLower(indicator)
+		}
+		if seen[[2]string{indicator, indicatorType}] {
+			continue
+		}
+		seen[[2]string{indicator, indicatorType}] = true
+
+		ret = append(ret, strings.Join([]string{
+			zeekField(indicator),
+			indicatorType,
+			zeekField(options.Source),
+			zeekField(options.Description),
+			zeekField(options.URL),
+		}, "\t"))
+	}
+
+	return strings.Join(ret, "\n")
+}
+
+// zeekField Make a value safe for a tab separated Zeek field, empty fields are written as -
+func zeekField(value string) string {
+	value = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(value)
+	if value == "" {
+		return "-"
+	}
+	return value
+}
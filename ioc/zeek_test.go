@@ -0,0 +1,40 @@
+package ioc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrintIOCsZeek(t *testing.T) {
+	iocs := []*IOC{
+		{"1[.]2[.]3[.]4", IPv4},
+		{"example[.]com", Domain},
+		{"hxxps[://]example[.]com/path?a=1", URL},
+		{"test[AT]example[.]com", Email},
+		{"874058E8D8582BF85C115CE319C5B0AF", MD5},
+		{"CVE-2016-0000", CVE},
+		// Duplicates once fanged
+		{"1(.)2(.)3(.)4", IPv4},
+		{"http://example.com/path?a=1", URL},
+		{"874058e8d8582bf85c115ce319c5b0af", MD5},
+	}
+
+	got := PrintIOCsZeek(iocs, ZeekOptions{Description: "bad\tthings", URL: "https://example.com/report"})
+	want := strings.Join([]string{
+		"#fields\tindicator\tindicator_type\tmeta.source\tmeta.desc\tmeta.url",
+		"1.2.3.4\tIntel::ADDR\tgo-ioc\tbad things\thttps://example.com/report",
+		"example.com\tIntel::DOMAIN\tgo-ioc\tbad things\thttps://example.com/report",
+		"example.com/path?a=1\tIntel::URL\tgo-ioc\tbad things\thttps://example.com/report",
+		"test@example.com\tIntel::EMAIL\tgo-ioc\tbad things\thttps://example.com/report",
+		"874058e8d8582bf85c115ce319c5b0af\tIntel::FILE_HASH\tgo-ioc\tbad things\thttps://example.com/report",
+	}, "\n")
+	if got != want {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, want)
+	}
+
+	// Empty metadata
+	got = PrintIOCsZeek([]*IOC{{"1.2.3.4", IPv4}}, ZeekOptions{Source: "feed"})
+	if want := "1.2.3.4\tIntel::ADDR\tfeed\t-\t-"; !strings.HasSuffix(got, "\n"+want) {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, want)
+	}
+}